@@ -0,0 +1,172 @@
+// Command metricsdiff compares metrics reports, such as the runs kept in savedmetrics
+// The first report is the baseline, and every other report is compared to it metric by metric.
+// Metrics match by name, or failing that by the function at the end of their call path.
+// It exits with status 1 if any metric got worse than the baseline by more than the threshold
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bcokert/engo-test/metrics"
+)
+
+// statistics are the values of a metric that can be compared, by name
+var statistics = map[string]func(metrics.Metric) int64{
+	"count": func(m metrics.Metric) int64 { return m.Count },
+	"sum":   func(m metrics.Metric) int64 { return m.Sum },
+	"avg":   func(m metrics.Metric) int64 { return m.Avg },
+	"self":  func(m metrics.Metric) int64 { return m.Self / m.Count },
+	"min":   func(m metrics.Metric) int64 { return m.Min },
+	"max":   func(m metrics.Metric) int64 { return m.Max },
+	"p50":   func(m metrics.Metric) int64 { return m.P50 },
+	"p90":   func(m metrics.Metric) int64 { return m.P90 },
+	"p99":   func(m metrics.Metric) int64 { return m.P99 },
+	"p999":  func(m metrics.Metric) int64 { return m.P999 },
+}
+
+func main() {
+	stat := flag.String("stat", "avg", "the statistic to compare: count, sum, avg, self (the average time outside of nested spans), min, max, p50, p90, p99 or p999")
+	threshold := flag.Float64("threshold", 10, "the percentage increase in a metric that counts as a regression")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] baseline report [report...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	value, ok := statistics[*stat]
+	if !ok || flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	reports := make([]metrics.Report, flag.NArg())
+	for i, path := range flag.Args() {
+		report, err := readReport(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			os.Exit(2)
+		}
+		reports[i] = report
+	}
+
+	regressed := false
+	for i := 1; i < len(reports); i++ {
+		fmt.Printf("Comparing %s to %s (%s, regression above %+.1f%%)\n\n", flag.Arg(i), flag.Arg(0), *stat, *threshold)
+		if compare(reports[0], reports[i], value, *threshold) {
+			regressed = true
+		}
+		fmt.Println()
+	}
+
+	if regressed {
+		fmt.Println("Regressions were found")
+		os.Exit(1)
+	}
+}
+
+func readReport(path string) (metrics.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return metrics.Report{}, err
+	}
+	defer file.Close()
+
+	return metrics.ParseReport(file)
+}
+
+// compare prints the change in every metric from the baseline to the report, and returns whether any regressed
+func compare(baseline, report metrics.Report, value func(metrics.Metric) int64, threshold float64) bool {
+	matches := match(baseline, report)
+	matched := make(map[string]bool, len(matches))
+	for _, a := range matches {
+		matched[a.Name] = true
+	}
+
+	// long call paths get a wider column, like the TextExporter's
+	width := 30
+	for _, ms := range [][]metrics.Metric{baseline.Metrics, report.Metrics} {
+		for _, m := range ms {
+			if len(m.Name) > width {
+				width = len(m.Name)
+			}
+		}
+	}
+
+	fmt.Printf("%*s   %12s   %12s   %12s   %9s\n", width, "Metric Name", "Before", "After", "Delta", "Change")
+
+	regressed := false
+	for _, m := range baseline.Metrics {
+		a, ok := matches[m.Name]
+		if !ok {
+			fmt.Printf("%*s   %12d   %12s   %12s   %9s\n", width, m.Name, value(m), "-", "-", "removed")
+			continue
+		}
+
+		b, c := value(m), value(a)
+		change := "-"
+		mark := ""
+		if b != 0 {
+			percent := float64(c-b) / float64(b) * 100
+			change = fmt.Sprintf("%+.1f%%", percent)
+			if percent > threshold {
+				regressed = true
+				mark = "   REGRESSION"
+			}
+		}
+		if a.Name != m.Name {
+			mark += fmt.Sprintf("   (was %s)", m.Name)
+		}
+		fmt.Printf("%*s   %12d   %12d   %+12d   %9s%s\n", width, a.Name, b, c, c-b, change, mark)
+	}
+
+	for _, m := range report.Metrics {
+		if !matched[m.Name] {
+			fmt.Printf("%*s   %12s   %12d   %12s   %9s\n", width, m.Name, "-", value(m), "-", "added")
+		}
+	}
+
+	return regressed
+}
+
+// match finds each baseline metric's metric in the report, by baseline name. Metrics with the same name match
+// Metrics are named by their call path, like Engine.Total/Engine.Integrate, so a baseline from before that, or with
+// a different caller, would match nothing. Any left over match by the last part of their path instead, as long as
+// it's unique among the left over metrics on both sides
+func match(baseline, report metrics.Report) map[string]metrics.Metric {
+	before := make(map[string]bool, len(baseline.Metrics))
+	for _, m := range baseline.Metrics {
+		before[m.Name] = true
+	}
+
+	matches := make(map[string]metrics.Metric, len(baseline.Metrics))
+	after := map[string][]metrics.Metric{} // the report's left over metrics, by leaf
+	for _, m := range report.Metrics {
+		if before[m.Name] {
+			matches[m.Name] = m
+		} else {
+			after[leaf(m.Name)] = append(after[leaf(m.Name)], m)
+		}
+	}
+
+	left := map[string][]string{} // the baseline's left over metric names, by leaf
+	for _, m := range baseline.Metrics {
+		if _, ok := matches[m.Name]; !ok {
+			left[leaf(m.Name)] = append(left[leaf(m.Name)], m.Name)
+		}
+	}
+	for name, b := range left {
+		if a := after[name]; len(b) == 1 && len(a) == 1 {
+			matches[b[0]] = a[0]
+		}
+	}
+	return matches
+}
+
+// leaf returns the last part of a metric's call path, which is the function it times
+func leaf(name string) string {
+	return name[strings.LastIndex(name, metrics.PathSeparator)+1:]
+}
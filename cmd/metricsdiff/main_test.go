@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcokert/engo-test/metrics"
+)
+
+func TestMatch(t *testing.T) {
+	baseline := metrics.Report{Metrics: []metrics.Metric{
+		{Name: "Engine.Total"},
+		{Name: "Engine.Integrate"},
+		{Name: "Registry.Add"},
+		{Name: "Engine.Total/Engine.Removed"},
+	}}
+	report := metrics.Report{Metrics: []metrics.Metric{
+		{Name: "Engine.Total"},
+		{Name: "Engine.Total/Engine.Integrate"},
+		{Name: "Engine.Total/Registry.Add"},
+		{Name: "Spawner.Update/Registry.Add"},
+		{Name: "Engine.Total/Engine.Added"},
+	}}
+
+	matches := match(baseline, report)
+	expected := map[string]string{
+		"Engine.Total":     "Engine.Total",
+		"Engine.Integrate": "Engine.Total/Engine.Integrate",
+	}
+	if len(matches) != len(expected) {
+		t.Errorf("expected %d matches, got %v", len(expected), matches)
+	}
+	for before, after := range expected {
+		if m, ok := matches[before]; !ok || m.Name != after {
+			t.Errorf("expected %s to match %s, got %q", before, after, m.Name)
+		}
+	}
+}
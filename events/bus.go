@@ -0,0 +1,97 @@
+package events
+
+import (
+	"engo.io/ecs"
+)
+
+// A Topic identifies a kind of event. Each event type has exactly one topic
+type Topic string
+
+// An Event is anything that can be published on a Bus
+type Event interface {
+	Topic() Topic
+}
+
+// A Bus delivers published events to every handler subscribed to their topic
+// Handlers are called synchronously, in the order they subscribed, so an event has been
+// fully handled by the time Publish returns. A bus should live exactly as long as the world it serves
+type Bus struct {
+	handlers map[Topic][]func(Event)
+}
+
+// NewBus constructs a bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[Topic][]func(Event), 8),
+	}
+}
+
+// Subscribe registers a handler for every event published on the given topic
+// Handlers receive the event as an Event, and should assert it to the type that the topic belongs to
+func (b *Bus) Subscribe(topic Topic, handler func(Event)) {
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish delivers the event to every handler subscribed to its topic
+func (b *Bus) Publish(event Event) {
+	for _, handler := range b.handlers[event.Topic()] {
+		handler(event)
+	}
+}
+
+const (
+	EntitySpawnedTopic Topic = "events.EntitySpawned"
+	EntityRemovedTopic Topic = "events.EntityRemoved"
+	SceneChangedTopic  Topic = "events.SceneChanged"
+)
+
+// An EntitySpawned event is published when an entity is created. Every system that manages
+// entities like it should check for the component interfaces it needs, and add it if it has them
+type EntitySpawned struct {
+	Entity Entity
+}
+
+// Entity is the minimum that every spawned entity provides
+type Entity interface {
+	BasicEntity() *ecs.BasicEntity
+}
+
+func (e EntitySpawned) Topic() Topic {
+	return EntitySpawnedTopic
+}
+
+// An EntityRemoved event is published when an entity should be removed from the world
+// The world removes it from every system once SubscribeWorld has been called, so despawning never needs the world itself
+type EntityRemoved struct {
+	Entity ecs.BasicEntity
+}
+
+func (e EntityRemoved) Topic() Topic {
+	return EntityRemovedTopic
+}
+
+// A SceneChanged event is published right before the game changes from one scene to another,
+// while the world being left still exists, so anything about it can be saved or cleaned up
+type SceneChanged struct {
+	From string // the type of the scene being left
+	To   string // the type of the scene being changed to
+}
+
+func (e SceneChanged) Topic() Topic {
+	return SceneChangedTopic
+}
+
+// OnEntitySpawned subscribes a handler to EntitySpawned events
+func (b *Bus) OnEntitySpawned(handler func(EntitySpawned)) {
+	b.Subscribe(EntitySpawnedTopic, func(e Event) { handler(e.(EntitySpawned)) })
+}
+
+// OnEntityRemoved subscribes a handler to EntityRemoved events
+func (b *Bus) OnEntityRemoved(handler func(EntityRemoved)) {
+	b.Subscribe(EntityRemovedTopic, func(e Event) { handler(e.(EntityRemoved)) })
+}
+
+// OnSceneChanged subscribes a handler to SceneChanged events
+func (b *Bus) OnSceneChanged(handler func(SceneChanged)) {
+	b.Subscribe(SceneChangedTopic, func(e Event) { handler(e.(SceneChanged)) })
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+
+	"engo.io/ecs"
+)
+
+func TestPublishDeliversToTypedHandlersInOrder(t *testing.T) {
+	bus := NewBus()
+	calls := []string{}
+	removed := ecs.NewBasic()
+
+	bus.OnEntityRemoved(func(e EntityRemoved) {
+		if e.Entity.ID() != removed.ID() {
+			t.Errorf("expected entity %d to be removed, got %d", removed.ID(), e.Entity.ID())
+		}
+		calls = append(calls, "removed 1")
+	})
+	bus.OnEntityRemoved(func(e EntityRemoved) { calls = append(calls, "removed 2") })
+	bus.OnSceneChanged(func(e SceneChanged) { calls = append(calls, "scene "+e.From+" to "+e.To) })
+
+	bus.Publish(EntityRemoved{Entity: removed})
+	bus.Publish(SceneChanged{From: "Title", To: "OwlClicker"})
+
+	expected := []string{"removed 1", "removed 2", "scene Title to OwlClicker"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestPublishWithoutSubscribers(t *testing.T) {
+	NewBus().Publish(SceneChanged{From: "Title", To: "OwlClicker"})
+}
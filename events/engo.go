@@ -0,0 +1,45 @@
+package events
+
+import (
+	"engo.io/ecs"
+	"engo.io/engo/common"
+)
+
+type renderable interface {
+	BasicEntity() *ecs.BasicEntity
+	RenderComponent() *common.RenderComponent
+	SpaceComponent() *common.SpaceComponent
+}
+
+type mouseable interface {
+	BasicEntity() *ecs.BasicEntity
+	MouseComponent() *common.MouseComponent
+	SpaceComponent() *common.SpaceComponent
+}
+
+// SubscribeWorld removes every entity published as removed from the world, and so from every system in it
+func SubscribeWorld(bus *Bus, world *ecs.World) {
+	bus.OnEntityRemoved(func(e EntityRemoved) {
+		world.RemoveEntity(e.Entity)
+	})
+}
+
+// SubscribeRenderSystem adds every spawned entity that can be rendered to the engo RenderSystem,
+// which can't subscribe to the bus by itself
+func SubscribeRenderSystem(bus *Bus, system *common.RenderSystem) {
+	bus.OnEntitySpawned(func(e EntitySpawned) {
+		if entity, ok := e.Entity.(renderable); ok {
+			system.Add(entity.BasicEntity(), entity.RenderComponent(), entity.SpaceComponent())
+		}
+	})
+}
+
+// SubscribeMouseSystem adds every spawned entity that can be clicked to the engo MouseSystem,
+// which can't subscribe to the bus by itself
+func SubscribeMouseSystem(bus *Bus, system *common.MouseSystem) {
+	bus.OnEntitySpawned(func(e EntitySpawned) {
+		if entity, ok := e.Entity.(mouseable); ok {
+			system.Add(entity.BasicEntity(), entity.MouseComponent(), entity.SpaceComponent(), nil)
+		}
+	})
+}
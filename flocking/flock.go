@@ -0,0 +1,206 @@
+package flocking
+
+import (
+	"github.com/engoengine/math"
+
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/metrics"
+	"github.com/bcokert/engo-test/physics"
+)
+
+// A boid is a single member of a flock
+type boid struct {
+	id      uint64
+	body    *physics.ParticleComponent
+	weights *Weights
+	center  engo.Point // the center of the body at the start of the step
+}
+
+// A cell is a square of the grid that boids are sorted into to find their neighbours
+type cell struct {
+	x, y int32
+}
+
+// A Flock is a ForceField that steers its boids by separation, alignment, cohesion and wall avoidance
+// Neighbours are found with a grid of cells as wide as the largest radius, so each boid only looks at the boids
+// in the 9 cells around it, rather than the whole flock
+type Flock struct {
+	walls    []physics.Wall
+	boids    map[uint64]*boid
+	grid     map[cell][]*boid
+	cellSize float32
+}
+
+// NewFlock constructs an empty flock whose boids avoid the given walls
+func NewFlock(walls []physics.Wall) *Flock {
+	return &Flock{
+		walls: walls,
+		boids: make(map[uint64]*boid, 32),
+		grid:  make(map[cell][]*boid, 32),
+	}
+}
+
+// Add adds a particle to the flock, which steers according to the given weights
+func (f *Flock) Add(id uint64, body *physics.ParticleComponent, weights *Weights) {
+	f.boids[id] = &boid{id: id, body: body, weights: weights}
+}
+
+// Remove removes a particle from the flock, if it was in it
+func (f *Flock) Remove(id uint64) {
+	delete(f.boids, id)
+}
+
+// Len returns the number of boids in the flock
+func (f *Flock) Len() int {
+	return len(f.boids)
+}
+
+// UpdateForces adds the flocking force to every boid's ForceAccumulator
+func (f *Flock) UpdateForces(dt float32) {
+	defer metrics.End(metrics.Start("Flock.UpdateForces"))
+
+	f.sort()
+	for _, b := range f.boids {
+		if b.body.InvMass == 0 {
+			continue
+		}
+
+		acceleration := f.acceleration(b)
+		if direction, length := acceleration.Normalize(); length > b.weights.MaxAcceleration {
+			acceleration = direction
+			acceleration.MultiplyScalar(b.weights.MaxAcceleration)
+		}
+
+		// F = m*a
+		acceleration.MultiplyScalar(1 / b.body.InvMass)
+		b.body.ForceAccumulator.Add(acceleration)
+	}
+}
+
+// sort puts every boid into the cell its center is in, with cells as wide as the largest radius in the flock
+func (f *Flock) sort() {
+	f.cellSize = 1
+	for _, b := range f.boids {
+		if b.weights.Radius > f.cellSize {
+			f.cellSize = b.weights.Radius
+		}
+	}
+
+	// cells that stayed empty through the last step are dropped, so the grid doesn't grow as the flock wanders,
+	// while the rest keep their slices to be refilled
+	for c, boids := range f.grid {
+		if len(boids) == 0 {
+			delete(f.grid, c)
+		} else {
+			f.grid[c] = boids[:0]
+		}
+	}
+	for _, b := range f.boids {
+		space := b.body.SpaceComponent
+		b.center = engo.Point{space.Position.X + space.Width/2, space.Position.Y + space.Height/2}
+		c := f.cellOf(b.center)
+		f.grid[c] = append(f.grid[c], b)
+	}
+}
+
+func (f *Flock) cellOf(p engo.Point) cell {
+	return cell{int32(math.Floor(p.X / f.cellSize)), int32(math.Floor(p.Y / f.cellSize))}
+}
+
+// acceleration sums the acceleration from every flocking rule for a single boid
+func (f *Flock) acceleration(b *boid) engo.Point {
+	w := b.weights
+	var separation, averageVelocity, averageCenter engo.Point
+	neighbours := 0
+
+	home := f.cellOf(b.center)
+	for x := home.x - 1; x <= home.x+1; x++ {
+		for y := home.y - 1; y <= home.y+1; y++ {
+			for _, other := range f.grid[cell{x, y}] {
+				if other == b {
+					continue
+				}
+
+				away := b.center
+				away.Subtract(other.center)
+				direction, distance := away.Normalize()
+				if distance > w.Radius {
+					continue
+				}
+
+				neighbours++
+				averageVelocity.Add(other.body.Velocity)
+				averageCenter.Add(other.center)
+
+				// the push grows from nothing at the edge of the separation radius to the full weight when touching
+				if distance < w.SeparationRadius {
+					direction.MultiplyScalar(w.Separation * (1 - distance/w.SeparationRadius))
+					separation.Add(direction)
+				}
+			}
+		}
+	}
+
+	acceleration := separation
+	if neighbours > 0 {
+		averageVelocity.MultiplyScalar(1 / float32(neighbours))
+		averageVelocity.Subtract(b.body.Velocity)
+		averageVelocity.MultiplyScalar(w.Alignment)
+		acceleration.Add(averageVelocity)
+
+		averageCenter.MultiplyScalar(1 / float32(neighbours))
+		averageCenter.Subtract(b.center)
+		averageCenter.MultiplyScalar(w.Cohesion)
+		acceleration.Add(averageCenter)
+	}
+
+	acceleration.Add(f.wallAvoidance(b))
+	return acceleration
+}
+
+// wallAvoidance pushes the boid away from every wall within its wall distance, harder the closer it is
+func (f *Flock) wallAvoidance(b *boid) engo.Point {
+	var acceleration engo.Point
+	w := b.weights
+	if w.WallDistance <= 0 || w.WallAvoidance <= 0 {
+		return acceleration
+	}
+
+	for _, wall := range f.walls {
+		away := b.center
+		away.Subtract(closestPoint(wall, b.center))
+		direction, distance := away.Normalize()
+		if distance >= w.WallDistance || distance == 0 {
+			continue
+		}
+
+		direction.MultiplyScalar(w.WallAvoidance * (1 - distance/w.WallDistance))
+		acceleration.Add(direction)
+	}
+
+	return acceleration
+}
+
+// closestPoint returns the point on the wall closest to p
+func closestPoint(wall physics.Wall, p engo.Point) engo.Point {
+	along := wall.P2
+	along.Subtract(wall.P1)
+	lengthSquared := engo.DotProduct(along, along)
+	if lengthSquared == 0 {
+		return wall.P1
+	}
+
+	toP := p
+	toP.Subtract(wall.P1)
+	t := engo.DotProduct(toP, along) / lengthSquared
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+
+	along.MultiplyScalar(t)
+	closest := wall.P1
+	closest.Add(along)
+	return closest
+}
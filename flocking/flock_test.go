@@ -0,0 +1,140 @@
+package flocking
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/physics"
+)
+
+type testParticle struct {
+	basicEntity       ecs.BasicEntity
+	particleComponent physics.ParticleComponent
+}
+
+func (p *testParticle) BasicEntity() *ecs.BasicEntity {
+	return &p.basicEntity
+}
+
+func (p *testParticle) ParticleComponent() *physics.ParticleComponent {
+	return &p.particleComponent
+}
+
+// newTestEngine builds an engine without gravity whose flock avoids the given walls
+func newTestEngine(walls []physics.Wall) (*physics.ParticleEngine, *Flock) {
+	e := physics.NewParticleEngine(engo.Point{}, 1, 0, walls, nil, nil)
+	flock := NewFlock(walls)
+	e.AddForceField(flock)
+	return e, flock
+}
+
+// addBoid adds a 10x10 boid centered on the given point to both the engine and the flock
+func addBoid(e *physics.ParticleEngine, flock *Flock, weights *Weights, center, velocity engo.Point) *testParticle {
+	p := &testParticle{
+		basicEntity:       ecs.NewBasic(),
+		particleComponent: physics.NewParticleComponent(10, 10, 1, engo.Point{center.X - 5, center.Y - 5}, velocity),
+	}
+	e.Add(p)
+	flock.Add(p.BasicEntity().ID(), p.ParticleComponent(), weights)
+	return p
+}
+
+// onlyRule returns weights that follow nothing but the rule set by the given function
+func onlyRule(set func(w *Weights)) *Weights {
+	w := &Weights{Radius: 120, SeparationRadius: 40, MaxAcceleration: 1000}
+	set(w)
+	return w
+}
+
+func TestFlockingRules(t *testing.T) {
+	cases := []struct {
+		name    string
+		weights *Weights
+		other   engo.Point // the center of a second boid, the first is at 100, 100
+		moving  engo.Point // the velocity of the second boid
+		check   func(v engo.Point) bool
+	}{
+		{"separation pushes close boids apart", onlyRule(func(w *Weights) { w.Separation = 400 }), engo.Point{120, 100}, engo.Point{}, func(v engo.Point) bool { return v.X < 0 }},
+		{"separation ignores boids outside its radius", onlyRule(func(w *Weights) { w.Separation = 400 }), engo.Point{160, 100}, engo.Point{}, func(v engo.Point) bool { return v == engo.Point{} }},
+		{"alignment matches neighbours' velocity", onlyRule(func(w *Weights) { w.Alignment = 1 }), engo.Point{160, 100}, engo.Point{0, 100}, func(v engo.Point) bool { return v.Y > 0 && engo.FloatEqual(v.X, 0) }},
+		{"cohesion pulls boids together", onlyRule(func(w *Weights) { w.Cohesion = 1 }), engo.Point{180, 100}, engo.Point{}, func(v engo.Point) bool { return v.X > 0 }},
+		{"nothing past the radius", onlyRule(func(w *Weights) { w.Alignment = 1; w.Cohesion = 1 }), engo.Point{300, 100}, engo.Point{0, 100}, func(v engo.Point) bool { return v == engo.Point{} }},
+	}
+
+	for _, c := range cases {
+		e, flock := newTestEngine(nil)
+		boid := addBoid(e, flock, c.weights, engo.Point{100, 100}, engo.Point{})
+		addBoid(e, flock, c.weights, c.other, c.moving)
+
+		e.Integrate(0.25)
+		if v := boid.ParticleComponent().Velocity; !c.check(v) {
+			t.Errorf("%s: unexpected velocity %v", c.name, v)
+		}
+	}
+}
+
+func TestFlockAvoidsWalls(t *testing.T) {
+	walls := []physics.Wall{{P1: engo.Point{0, 0}, P2: engo.Point{200, 0}}}
+	e, flock := newTestEngine(walls)
+	weights := onlyRule(func(w *Weights) { w.WallAvoidance = 600; w.WallDistance = 60 })
+	near := addBoid(e, flock, weights, engo.Point{100, 20}, engo.Point{})
+	past := addBoid(e, flock, weights, engo.Point{300, 20}, engo.Point{})
+
+	e.Integrate(0.25)
+	if v := near.ParticleComponent().Velocity; v.Y <= 0 || !engo.FloatEqual(v.X, 0) {
+		t.Errorf("expected to be pushed down away from the wall, got velocity %v", v)
+	}
+	if v := past.ParticleComponent().Velocity; v != (engo.Point{}) {
+		t.Errorf("expected the wall to end before the second boid, got velocity %v", v)
+	}
+}
+
+func TestFlockLimitsAcceleration(t *testing.T) {
+	e, flock := newTestEngine(nil)
+	weights := onlyRule(func(w *Weights) { w.Separation = 100000; w.MaxAcceleration = 100 })
+	boid := addBoid(e, flock, weights, engo.Point{100, 100}, engo.Point{})
+	addBoid(e, flock, weights, engo.Point{101, 100}, engo.Point{})
+
+	e.Integrate(0.25)
+	if _, speed := boid.ParticleComponent().Velocity.Normalize(); speed > 100*0.25+0.001 {
+		t.Errorf("expected at most 25 pixels/second after a quarter second, got %v", speed)
+	}
+}
+
+func TestWeightsDefaultMissingFields(t *testing.T) {
+	var w Weights
+	if err := json.Unmarshal([]byte(`{"cohesion": 2}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultWeights()
+	expected.Cohesion = 2
+	if w != expected {
+		t.Errorf("expected %+v, got %+v", expected, w)
+	}
+	if problems := w.Validate(); len(problems) != 0 {
+		t.Errorf("expected the defaults to be legal, got %v", problems)
+	}
+}
+
+func BenchmarkFlock(b *testing.B) {
+	e, flock := newTestEngine([]physics.Wall{
+		{P1: engo.Point{0, 0}, P2: engo.Point{800, 0}},
+		{P1: engo.Point{800, 0}, P2: engo.Point{800, 600}},
+		{P1: engo.Point{800, 600}, P2: engo.Point{0, 600}},
+		{P1: engo.Point{0, 600}, P2: engo.Point{0, 0}},
+	})
+	weights := DefaultWeights()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		addBoid(e, flock, &weights, engo.Point{r.Float32() * 800, r.Float32() * 600}, engo.Point{r.Float32()*100 - 50, r.Float32()*100 - 50})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		flock.UpdateForces(1.0 / 60)
+	}
+}
@@ -0,0 +1,50 @@
+package flocking
+
+import (
+	"engo.io/ecs"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/physics"
+)
+
+type boidEntity interface {
+	BasicEntity() *ecs.BasicEntity
+	ParticleComponent() *physics.ParticleComponent
+	FlockingWeights() *Weights
+}
+
+// The FlockSystem adds a flock to a physics engine, and keeps its boids in sync with the world
+// Every spawned entity with flocking weights is added automatically. Entities without weights don't flock
+type FlockSystem struct {
+	Engine *physics.ParticleEngine // the engine that the flock's forces are applied in
+	Bus    *events.Bus             // where spawned entities come from
+	flock  *Flock
+}
+
+// Add adds an entity to the flock, unless it has no flocking weights
+func (s *FlockSystem) Add(entity boidEntity) {
+	weights := entity.FlockingWeights()
+	if weights == nil {
+		return
+	}
+	s.flock.Add(entity.BasicEntity().ID(), entity.ParticleComponent(), weights)
+}
+
+// Remove removes an entity from the flock
+func (s *FlockSystem) Remove(entity ecs.BasicEntity) {
+	s.flock.Remove(entity.ID())
+}
+
+// New is called every time the system is added to a world
+func (s *FlockSystem) New(world *ecs.World) {
+	s.flock = NewFlock(s.Engine.Walls())
+	s.Engine.AddForceField(s.flock)
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(boidEntity); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// Update does nothing, since the flock's forces are applied by the physics engine every step
+func (s *FlockSystem) Update(dt float32) {}
@@ -0,0 +1,66 @@
+package flocking
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Weights tune how strongly a boid follows each of the flocking rules
+// Every rule produces an acceleration, so boids of any mass flock the same way
+type Weights struct {
+	Radius           float32 `json:"radius"`           // other boids closer than this, center to center in pixels, are neighbours
+	SeparationRadius float32 `json:"separationRadius"` // neighbours closer than this are steered away from
+	Separation       float32 `json:"separation"`       // pixels/second^2 when a neighbour is right on top of the boid
+	Alignment        float32 `json:"alignment"`        // pixels/second^2 for each pixel/second the boid differs from its neighbours' average velocity
+	Cohesion         float32 `json:"cohesion"`         // pixels/second^2 for each pixel the boid is from its neighbours' average center
+	WallAvoidance    float32 `json:"wallAvoidance"`    // pixels/second^2 when the boid is touching a wall
+	WallDistance     float32 `json:"wallDistance"`     // walls closer than this, in pixels, are steered away from
+	MaxAcceleration  float32 `json:"maxAcceleration"`  // the combined acceleration from all rules is limited to this, in pixels/second^2
+}
+
+// DefaultWeights returns weights that make a loose, lively flock
+func DefaultWeights() Weights {
+	return Weights{
+		Radius:           120,
+		SeparationRadius: 40,
+		Separation:       400,
+		Alignment:        1,
+		Cohesion:         0.5,
+		WallAvoidance:    600,
+		WallDistance:     60,
+		MaxAcceleration:  800,
+	}
+}
+
+// UnmarshalJSON fills in any weights missing from the JSON with the DefaultWeights,
+// so an archetype can flock with "flocking": {} and only tune the weights it cares about
+func (w *Weights) UnmarshalJSON(data []byte) error {
+	type plain Weights // without this method, so decoding it doesn't recurse
+	weights := plain(DefaultWeights())
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return err
+	}
+	*w = Weights(weights)
+	return nil
+}
+
+// Validate returns a description of every problem in the weights, or nothing if they are legal
+func (w Weights) Validate() []string {
+	problems := []string{}
+	if w.Radius <= 0 {
+		problems = append(problems, fmt.Sprintf("flocking radius must be positive, got %v", w.Radius))
+	}
+	if w.SeparationRadius < 0 || w.SeparationRadius > w.Radius {
+		problems = append(problems, fmt.Sprintf("flocking separationRadius must be between 0 and the radius %v, got %v", w.Radius, w.SeparationRadius))
+	}
+	if w.Separation < 0 || w.Alignment < 0 || w.Cohesion < 0 || w.WallAvoidance < 0 {
+		problems = append(problems, fmt.Sprintf("flocking weights must not be negative, got separation %v, alignment %v, cohesion %v and wallAvoidance %v", w.Separation, w.Alignment, w.Cohesion, w.WallAvoidance))
+	}
+	if w.WallDistance < 0 {
+		problems = append(problems, fmt.Sprintf("flocking wallDistance must not be negative, got %v", w.WallDistance))
+	}
+	if w.MaxAcceleration <= 0 {
+		problems = append(problems, fmt.Sprintf("flocking maxAcceleration must be positive, got %v", w.MaxAcceleration))
+	}
+	return problems
+}
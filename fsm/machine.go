@@ -0,0 +1,117 @@
+package fsm
+
+import (
+	"fmt"
+)
+
+// A State is one of the states a Machine can be in. Every hook is optional
+type State struct {
+	Enter  func()           // called when the machine moves into the state
+	Exit   func()           // called when the machine moves out of the state
+	Update func(dt float32) // called every update while the machine is in the state
+}
+
+// A Machine is a finite state machine, which is in exactly one of its states at a time once started
+// States can move the machine on from their hooks with Transition, or schedule it with After
+type Machine struct {
+	states  map[string]State
+	current string
+	elapsed float32 // seconds spent in the current state
+	timer   float32 // seconds until the timed transition, if there is one
+	next    string  // the state of the timed transition, or empty if there is none
+}
+
+// NewMachine constructs a machine with no states
+func NewMachine() *Machine {
+	return &Machine{
+		states: make(map[string]State, 8),
+	}
+}
+
+// Add adds a state to the machine under the given name, replacing any state already using it
+func (m *Machine) Add(name string, state State) {
+	m.states[name] = state
+}
+
+// Start enters the named state without exiting any other, and must be called before the machine is updated
+func (m *Machine) Start(name string) error {
+	state, ok := m.states[name]
+	if !ok {
+		return fmt.Errorf("The state machine has no state named %q", name)
+	}
+
+	m.enter(name, state)
+	return nil
+}
+
+// Transition exits the current state and enters the named one. Transitioning to the current state re-enters it
+// Any timed transition is cancelled. Unknown states are an error, and leave the machine where it was
+func (m *Machine) Transition(name string) error {
+	state, ok := m.states[name]
+	if !ok {
+		return fmt.Errorf("The state machine has no state named %q", name)
+	}
+
+	if exit := m.states[m.current].Exit; exit != nil {
+		exit()
+	}
+	m.enter(name, state)
+	return nil
+}
+
+// After schedules a transition to the named state once the machine has spent the given seconds in the current state from now
+// Only one timed transition is kept; scheduling another replaces it
+func (m *Machine) After(seconds float32, name string) {
+	m.timer = seconds
+	m.next = name
+}
+
+// Current returns the name of the current state
+func (m *Machine) Current() string {
+	return m.current
+}
+
+// Is returns whether the machine is in any of the named states
+func (m *Machine) Is(names ...string) bool {
+	for _, name := range names {
+		if m.current == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Elapsed returns the number of seconds spent in the current state
+func (m *Machine) Elapsed() float32 {
+	return m.elapsed
+}
+
+// Update updates the current state, then makes the timed transition if its time has come
+func (m *Machine) Update(dt float32) error {
+	m.elapsed += dt
+	current := m.current
+	if update := m.states[current].Update; update != nil {
+		update(dt)
+	}
+
+	// the update may have moved the machine on already, which replaces the old state's timer
+	if m.current != current || m.next == "" {
+		return nil
+	}
+
+	m.timer -= dt
+	if m.timer > 0 {
+		return nil
+	}
+	return m.Transition(m.next)
+}
+
+func (m *Machine) enter(name string, state State) {
+	m.current = name
+	m.elapsed = 0
+	m.timer = 0
+	m.next = ""
+	if state.Enter != nil {
+		state.Enter()
+	}
+}
@@ -0,0 +1,133 @@
+package fsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// recordingMachine makes a machine whose states append their hooks to calls as they're called
+func recordingMachine(calls *[]string, names ...string) *Machine {
+	m := NewMachine()
+	for _, name := range names {
+		name := name
+		m.Add(name, State{
+			Enter: func() { *calls = append(*calls, "enter "+name) },
+			Exit:  func() { *calls = append(*calls, "exit "+name) },
+		})
+	}
+	return m
+}
+
+func TestTransitionExitsThenEnters(t *testing.T) {
+	calls := []string{}
+	m := recordingMachine(&calls, "a", "b")
+
+	if err := m.Start("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Transition("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Transition("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"enter a", "exit a", "enter b", "exit b", "enter b"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+	if m.Current() != "b" {
+		t.Errorf("expected to be in b, got %s", m.Current())
+	}
+}
+
+func TestTransitionToUnknownState(t *testing.T) {
+	calls := []string{}
+	m := recordingMachine(&calls, "a")
+	m.Start("a")
+
+	if err := m.Transition("missing"); err == nil {
+		t.Errorf("expected an error transitioning to a missing state")
+	}
+	if m.Current() != "a" || len(calls) != 1 {
+		t.Errorf("expected to still be in a without exiting it, got %s after %v", m.Current(), calls)
+	}
+	if err := NewMachine().Start("missing"); err == nil {
+		t.Errorf("expected an error starting in a missing state")
+	}
+}
+
+func TestAfterTransitionsOnTime(t *testing.T) {
+	calls := []string{}
+	m := recordingMachine(&calls, "a", "b")
+	m.Start("a")
+	m.After(1, "b")
+
+	m.Update(0.5)
+	if m.Current() != "a" || m.Elapsed() != 0.5 {
+		t.Errorf("expected to be 0.5s into a, got %vs into %s", m.Elapsed(), m.Current())
+	}
+	m.Update(0.5)
+	if m.Current() != "b" || m.Elapsed() != 0 {
+		t.Errorf("expected to have just entered b, got %vs into %s", m.Elapsed(), m.Current())
+	}
+
+	// the timed transition only happens once
+	m.Update(5)
+	if expected := []string{"enter a", "exit a", "enter b"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestAfterReplacesTimer(t *testing.T) {
+	calls := []string{}
+	m := recordingMachine(&calls, "a", "b", "c")
+	m.Start("a")
+	m.After(1, "b")
+	m.After(2, "c")
+
+	m.Update(1)
+	if m.Current() != "a" {
+		t.Errorf("expected the first timer to be replaced, got %s", m.Current())
+	}
+	m.Update(1)
+	if m.Current() != "c" {
+		t.Errorf("expected the second timer to move to c, got %s", m.Current())
+	}
+}
+
+func TestTransitionCancelsTimer(t *testing.T) {
+	calls := []string{}
+	m := recordingMachine(&calls, "a", "b", "c")
+	m.Start("a")
+	m.After(1, "b")
+
+	m.Transition("c")
+	m.Update(2)
+	if m.Current() != "c" {
+		t.Errorf("expected the transition to cancel the timer, got %s", m.Current())
+	}
+}
+
+func TestTimerSetOnEnterReplacesOldOne(t *testing.T) {
+	m := NewMachine()
+	m.Add("a", State{Update: func(dt float32) { m.Transition("b") }})
+	m.Add("b", State{Enter: func() { m.After(1, "c") }})
+	m.Add("c", State{})
+	m.Start("a")
+	m.After(0.25, "c")
+
+	// a's timer is replaced by the one b sets when it's entered, so the old one doesn't fire in the same update
+	m.Update(0.5)
+	if m.Current() != "b" {
+		t.Errorf("expected to be in b, got %s", m.Current())
+	}
+	m.Update(0.5)
+	if m.Current() != "b" {
+		t.Errorf("expected b's timer to not be done, got %s", m.Current())
+	}
+	m.Update(0.5)
+	if m.Current() != "c" {
+		t.Errorf("expected b's timer to move to c, got %s", m.Current())
+	}
+}
@@ -0,0 +1,165 @@
+package health
+
+import (
+	"fmt"
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+)
+
+// A BarStyle configures how health bars look
+type BarStyle struct {
+	Height       float32    `json:"height"`
+	Offset       engo.Point `json:"offset"` // from the top left of the entity to the top left of its bar
+	Full         color.RGBA `json:"full"`   // the color of the remaining health
+	Empty        color.RGBA `json:"empty"`  // the color of the lost health
+	HideWhenFull bool       `json:"hideWhenFull"`
+}
+
+// DefaultBarStyle returns a green and red bar just above the entity
+func DefaultBarStyle() BarStyle {
+	return BarStyle{
+		Height: 6,
+		Offset: engo.Point{0, -12},
+		Full:   color.RGBA{0, 255, 0, 255},
+		Empty:  color.RGBA{255, 0, 0, 255},
+	}
+}
+
+// Validate returns a description of every problem in the style, or nothing if it is legal
+func (s BarStyle) Validate() []string {
+	problems := []string{}
+	if s.Height <= 0 {
+		problems = append(problems, fmt.Sprintf("health bar height must be positive, got %v", s.Height))
+	}
+	return problems
+}
+
+type barEntity interface {
+	BasicEntity() *ecs.BasicEntity
+	HealthComponent() *Component
+	SpaceComponent() *common.SpaceComponent
+}
+
+// A barPart is one of the rectangles that make up a health bar
+type barPart struct {
+	basic  ecs.BasicEntity
+	render common.RenderComponent
+	space  common.SpaceComponent
+}
+
+func newBarPart(col color.RGBA, height float32) *barPart {
+	part := &barPart{
+		basic:  ecs.NewBasic(),
+		render: common.RenderComponent{Drawable: common.Rectangle{}, Color: col},
+		space:  common.SpaceComponent{Height: height},
+	}
+	part.render.SetZIndex(1)
+	return part
+}
+
+func (p *barPart) BasicEntity() *ecs.BasicEntity {
+	return &p.basic
+}
+
+func (p *barPart) RenderComponent() *common.RenderComponent {
+	return &p.render
+}
+
+func (p *barPart) SpaceComponent() *common.SpaceComponent {
+	return &p.space
+}
+
+// A bar is the pair of rectangles that make up a health bar. The full part shrinks from the left as health is lost
+type bar struct {
+	parent barEntity
+	empty  *barPart
+	full   *barPart
+}
+
+// The BarSystem draws a health bar above every entity with health, as wide as the entity,
+// and removes the bar along with the entity
+type BarSystem struct {
+	Style BarStyle
+	Bus   *events.Bus // where spawned entities come from, and where their bars are spawned and removed
+	bars  map[uint64]*bar
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+// Bars are positioned after everything has moved, right before rendering
+func (s *BarSystem) Priority() int {
+	return -500
+}
+
+// Add creates a bar for the entity and spawns its parts, so that they are rendered
+func (s *BarSystem) Add(entity barEntity) {
+	b := &bar{
+		parent: entity,
+		empty:  newBarPart(s.Style.Empty, s.Style.Height),
+		full:   newBarPart(s.Style.Full, s.Style.Height),
+	}
+	s.update(b)
+
+	s.bars[entity.BasicEntity().ID()] = b
+
+	s.Bus.Publish(events.EntitySpawned{Entity: b.empty})
+	s.Bus.Publish(events.EntitySpawned{Entity: b.full})
+}
+
+// Remove removes the entity's bar, if it has one
+func (s *BarSystem) Remove(entity ecs.BasicEntity) {
+	b, ok := s.bars[entity.ID()]
+	if !ok {
+		return
+	}
+	delete(s.bars, entity.ID())
+
+	s.Bus.Publish(events.EntityRemoved{Entity: b.empty.basic})
+	s.Bus.Publish(events.EntityRemoved{Entity: b.full.basic})
+}
+
+// New is called every time the system is added to a world
+func (s *BarSystem) New(world *ecs.World) {
+	if s.Style.Height <= 0 {
+		s.Style = DefaultBarStyle()
+	}
+	if s.bars == nil {
+		s.bars = make(map[uint64]*bar, 10)
+	}
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(barEntity); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// Update moves every bar to its entity, and resizes it to match the entity's health
+func (s *BarSystem) Update(dt float32) {
+	for _, b := range s.bars {
+		s.update(b)
+	}
+}
+
+func (s *BarSystem) update(b *bar) {
+	health := b.parent.HealthComponent()
+	space := b.parent.SpaceComponent()
+	percent := health.Percent()
+
+	hidden := s.Style.HideWhenFull && percent >= 1
+	b.empty.render.Hidden = hidden
+	b.full.render.Hidden = hidden
+
+	position := space.Position
+	position.Add(s.Style.Offset)
+
+	b.full.space.Width = percent * space.Width
+	b.empty.space.Width = space.Width - b.full.space.Width
+
+	b.empty.space.Position = position
+	b.full.space.Position = position
+	b.full.space.Position.X += b.empty.space.Width
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"fmt"
+)
+
+// A DamageType describes what caused some damage, so that entities can resist some kinds more than others
+type DamageType int
+
+const (
+	Click  DamageType = iota
+	Impact DamageType = iota
+)
+
+func (t DamageType) String() string {
+	switch t {
+	case Click:
+		return "Click"
+	case Impact:
+		return "Impact"
+	default:
+		return fmt.Sprintf("DamageType(%d)", int(t))
+	}
+}
+
+// Damage is an amount of health to take from an entity
+type Damage struct {
+	Amount float32
+	Type   DamageType
+	Source uint64 // the id of the entity that caused the damage, or 0 if it wasn't an entity
+}
+
+// A Component is the health of an entity that can be damaged and killed
+// It must be made legal - use NewComponent to guarantee this
+type Component struct {
+	Health          float32
+	MaxHealth       float32
+	Regeneration    float32                // health regained per second, up to MaxHealth
+	Invulnerability float32                // seconds after being damaged during which no more damage is taken
+	Resistances     map[DamageType]float32 // the fraction of each type of damage that is ignored, between 0 and 1
+	invulnerable    float32                // seconds of invulnerability left
+	dead            bool
+}
+
+// NewComponent constructs a component at full health
+func NewComponent(maxHealth, regeneration, invulnerability float32) Component {
+	return Component{
+		Health:          maxHealth,
+		MaxHealth:       maxHealth,
+		Regeneration:    regeneration,
+		Invulnerability: invulnerability,
+		Resistances:     map[DamageType]float32{},
+	}
+}
+
+// Percent returns the fraction of max health that is left, between 0 and 1
+func (c *Component) Percent() float32 {
+	if c.MaxHealth <= 0 || c.Health <= 0 {
+		return 0
+	}
+	if c.Health >= c.MaxHealth {
+		return 1
+	}
+	return c.Health / c.MaxHealth
+}
+
+// Invulnerable returns whether the entity is currently ignoring damage
+func (c *Component) Invulnerable() bool {
+	return c.invulnerable > 0
+}
+
+// Dead returns whether the entity has run out of health
+func (c *Component) Dead() bool {
+	return c.dead
+}
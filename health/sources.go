@@ -0,0 +1,173 @@
+package health
+
+import (
+	"fmt"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/physics"
+)
+
+type clickableEntity interface {
+	BasicEntity() *ecs.BasicEntity
+	MouseComponent() *common.MouseComponent
+}
+
+// A Dragger tells the ClickSystem which presses dragged an entity rather than clicking it
+type Dragger interface {
+	Dragged(id uint64) bool
+}
+
+// The ClickSystem damages entities when they are clicked
+// The damage is dealt when the button is released, so that a press which turns into a drag does no damage
+// Every spawned entity with a MouseComponent is added automatically
+type ClickSystem struct {
+	Health         *System     // the system that damage is dealt through
+	Bus            *events.Bus // where spawned entities come from
+	Drags          Dragger     // the entities that were dragged rather than clicked. If nil, every press is a click
+	DamagePerClick float32
+	entities       map[uint64]clickableEntity
+	pressed        map[uint64]bool // the entities the button is being held on
+	paused         bool
+}
+
+// Add adds a new entity to the system
+func (s *ClickSystem) Add(entity clickableEntity) {
+	s.entities[entity.BasicEntity().ID()] = entity
+}
+
+// Remove removes an entity from the system, by its entity id
+func (s *ClickSystem) Remove(entity ecs.BasicEntity) {
+	delete(s.entities, entity.ID())
+	delete(s.pressed, entity.ID())
+}
+
+// New is called every time the system is added to a world
+func (s *ClickSystem) New(world *ecs.World) {
+	if s.DamagePerClick <= 0 {
+		s.DamagePerClick = 1
+	}
+	if s.entities == nil {
+		s.entities = make(map[uint64]clickableEntity, 10)
+	}
+	s.pressed = make(map[uint64]bool, 1)
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(clickableEntity); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// SetPaused stops or restarts clicks from doing damage. A press in progress is forgotten
+func (s *ClickSystem) SetPaused(paused bool) {
+	s.paused = paused
+	if paused {
+		s.pressed = make(map[uint64]bool, 1)
+	}
+}
+
+// Update deals damage to every entity that was just clicked, unless the press dragged it
+func (s *ClickSystem) Update(dt float32) {
+	if s.paused {
+		return
+	}
+
+	for id, entity := range s.entities {
+		if entity.MouseComponent().Clicked {
+			s.pressed[id] = true
+		}
+	}
+	if engo.Input.Mouse.Action != engo.Release {
+		return
+	}
+
+	for id := range s.pressed {
+		delete(s.pressed, id)
+		if s.Drags != nil && s.Drags.Dragged(id) {
+			continue
+		}
+		s.Health.Damage(id, Damage{Amount: s.DamagePerClick, Type: Click})
+	}
+}
+
+// ImpactRules configure how much damage entities take from collisions
+type ImpactRules struct {
+	Threshold      float32 `json:"threshold"`      // impacts slower than this, in pixels/second, do no damage
+	DamagePerSpeed float32 `json:"damagePerSpeed"` // damage for each pixel/second of impact speed above the threshold
+}
+
+// DefaultImpactRules returns the rules used when none are configured
+func DefaultImpactRules() ImpactRules {
+	return ImpactRules{
+		Threshold:      400,
+		DamagePerSpeed: 0.01,
+	}
+}
+
+// Validate returns a description of every problem in the rules, or nothing if they are legal
+func (r ImpactRules) Validate() []string {
+	problems := []string{}
+	if r.Threshold < 0 {
+		problems = append(problems, fmt.Sprintf("impact threshold must not be negative, got %v", r.Threshold))
+	}
+	if r.DamagePerSpeed < 0 {
+		problems = append(problems, fmt.Sprintf("impact damagePerSpeed must not be negative, got %v", r.DamagePerSpeed))
+	}
+	return problems
+}
+
+// Damage returns the damage done by an impact at the given speed
+func (r ImpactRules) Damage(speed float32) float32 {
+	if speed <= r.Threshold {
+		return 0
+	}
+	return (speed - r.Threshold) * r.DamagePerSpeed
+}
+
+// The ImpactSystem damages the participants of every collision published by the physics system
+// Entities that aren't in the health system are unaffected, so walls and other bodies can take part safely
+type ImpactSystem struct {
+	Health *System     // the system that damage is dealt through
+	Bus    *events.Bus // where collisions come from
+	Rules  ImpactRules
+	paused bool
+}
+
+// Remove does nothing, since the ImpactSystem works from collisions rather than entities
+func (s *ImpactSystem) Remove(entity ecs.BasicEntity) {}
+
+// New is called every time the system is added to a world
+func (s *ImpactSystem) New(world *ecs.World) {
+	physics.OnCollided(s.Bus, s.onCollided)
+}
+
+// SetPaused stops or restarts collisions from doing damage
+func (s *ImpactSystem) SetPaused(paused bool) {
+	s.paused = paused
+}
+
+// Update does nothing, since damage is dealt as collisions are published
+func (s *ImpactSystem) Update(dt float32) {}
+
+// onCollided deals damage to both participants of a collision, based on how fast they hit
+func (s *ImpactSystem) onCollided(collided physics.Collided) {
+	if s.paused {
+		return
+	}
+
+	contact := collided.Contact
+	damage := s.Rules.Damage(contact.Speed)
+	if damage <= 0 {
+		return
+	}
+
+	if contact.Wall {
+		s.Health.Damage(contact.A, Damage{Amount: damage, Type: Impact})
+		return
+	}
+	s.Health.Damage(contact.A, Damage{Amount: damage, Type: Impact, Source: contact.B})
+	s.Health.Damage(contact.B, Damage{Amount: damage, Type: Impact, Source: contact.A})
+}
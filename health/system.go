@@ -0,0 +1,164 @@
+package health
+
+import (
+	"engo.io/ecs"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+)
+
+const (
+	DamagedTopic events.Topic = "health.Damaged"
+	DeathTopic   events.Topic = "health.Death"
+)
+
+type healthEntity interface {
+	BasicEntity() *ecs.BasicEntity
+	HealthComponent() *Component
+}
+
+// A Damaged event is published whenever an entity loses health
+type Damaged struct {
+	Entity *ecs.BasicEntity
+	Damage Damage  // the damage as it was dealt
+	Taken  float32 // the health actually lost, after resistances
+}
+
+func (e Damaged) Topic() events.Topic {
+	return DamagedTopic
+}
+
+// A Death event is published once when an entity runs out of health
+type Death struct {
+	Entity *ecs.BasicEntity
+	Damage Damage // the damage that killed the entity
+}
+
+func (e Death) Topic() events.Topic {
+	return DeathTopic
+}
+
+// OnDamaged subscribes a handler to Damaged events
+func OnDamaged(bus *events.Bus, handler func(Damaged)) {
+	bus.Subscribe(DamagedTopic, func(e events.Event) { handler(e.(Damaged)) })
+}
+
+// OnDeath subscribes a handler to Death events
+func OnDeath(bus *events.Bus, handler func(Death)) {
+	bus.Subscribe(DeathTopic, func(e events.Event) { handler(e.(Death)) })
+}
+
+type pendingDamage struct {
+	id     uint64
+	damage Damage
+}
+
+// The System applies damage to entities, regenerates their health, and publishes when they are damaged or die
+// Damage can be dealt by anything at any time with Damage; it is applied during the next Update
+// Every spawned entity with a HealthComponent is added automatically
+type System struct {
+	Log      logging.Logger
+	Bus      *events.Bus // where spawned entities come from, and damage and deaths are published
+	entities map[uint64]healthEntity
+	pending  []pendingDamage
+	paused   bool
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+// Health runs after the systems that deal damage, so damage is applied on the frame it's dealt
+func (s *System) Priority() int {
+	return -50
+}
+
+// Add adds a new entity to the system
+func (s *System) Add(entity healthEntity) {
+	s.entities[entity.BasicEntity().ID()] = entity
+}
+
+// Remove removes an entity from the system, by its entity id
+func (s *System) Remove(entity ecs.BasicEntity) {
+	delete(s.entities, entity.ID())
+}
+
+// New is called every time the system is added to a world
+func (s *System) New(world *ecs.World) {
+	if s.entities == nil {
+		s.entities = make(map[uint64]healthEntity, 10)
+	}
+	s.pending = s.pending[:0]
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(healthEntity); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// SetPaused stops or restarts regeneration and invulnerability from counting down
+func (s *System) SetPaused(paused bool) {
+	s.paused = paused
+}
+
+// Damage queues damage for the entity with the given id. Entities that aren't in the system are ignored
+func (s *System) Damage(id uint64, damage Damage) {
+	s.pending = append(s.pending, pendingDamage{id: id, damage: damage})
+}
+
+// Update applies the queued damage, then regenerates health and counts down invulnerability
+func (s *System) Update(dt float32) {
+	if s.paused {
+		return
+	}
+
+	damaged := make([]Damaged, 0, len(s.pending))
+	deaths := []Death{}
+
+	for _, pending := range s.pending {
+		entity, ok := s.entities[pending.id]
+		if !ok {
+			continue
+		}
+		health := entity.HealthComponent()
+		if health.dead || health.invulnerable > 0 || pending.damage.Amount <= 0 {
+			continue
+		}
+
+		taken := pending.damage.Amount * (1 - health.Resistances[pending.damage.Type])
+		if taken <= 0 {
+			continue
+		}
+		health.Health -= taken
+		health.invulnerable = health.Invulnerability
+		damaged = append(damaged, Damaged{Entity: entity.BasicEntity(), Damage: pending.damage, Taken: taken})
+		s.Log.Debug("Entity damaged", logging.F{"id": pending.id, "damage": pending.damage, "taken": taken, "health": health.Health})
+
+		if health.Health <= 0 {
+			health.dead = true
+			deaths = append(deaths, Death{Entity: entity.BasicEntity(), Damage: pending.damage})
+		}
+	}
+	s.pending = s.pending[:0]
+
+	for _, entity := range s.entities {
+		health := entity.HealthComponent()
+		if health.dead {
+			continue
+		}
+		if health.invulnerable > 0 {
+			health.invulnerable -= dt
+		}
+		if health.Regeneration > 0 && health.Health < health.MaxHealth {
+			health.Health += health.Regeneration * dt
+			if health.Health > health.MaxHealth {
+				health.Health = health.MaxHealth
+			}
+		}
+	}
+
+	// events are published last, so subscribers are free to remove entities
+	for _, event := range damaged {
+		s.Bus.Publish(event)
+	}
+	for _, event := range deaths {
+		s.Bus.Publish(event)
+	}
+}
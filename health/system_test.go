@@ -0,0 +1,222 @@
+package health
+
+import (
+	"testing"
+
+	"engo.io/ecs"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+)
+
+type testEntity struct {
+	basicEntity     ecs.BasicEntity
+	healthComponent Component
+}
+
+func (e *testEntity) BasicEntity() *ecs.BasicEntity {
+	return &e.basicEntity
+}
+
+func (e *testEntity) HealthComponent() *Component {
+	return &e.healthComponent
+}
+
+// newTestSystem makes a system on its own bus, counting the events it publishes
+func newTestSystem() (*System, *[]Damaged, *[]Death) {
+	bus := events.NewBus()
+	s := &System{Log: logging.NewDefaultLogger(logging.INFO), Bus: bus}
+	s.New(nil)
+
+	damaged, deaths := []Damaged{}, []Death{}
+	OnDamaged(bus, func(e Damaged) { damaged = append(damaged, e) })
+	OnDeath(bus, func(e Death) { deaths = append(deaths, e) })
+	return s, &damaged, &deaths
+}
+
+func addTestEntity(s *System, component Component) *testEntity {
+	e := &testEntity{basicEntity: ecs.NewBasic(), healthComponent: component}
+	s.Bus.Publish(events.EntitySpawned{Entity: e})
+	return e
+}
+
+func TestSystemAppliesDamage(t *testing.T) {
+	cases := []struct {
+		name         string
+		resistances  map[DamageType]float32
+		invulnerable float32
+		damage       []Damage
+		health       float32
+		damaged      int
+		deaths       int
+	}{
+		{"single hit", nil, 0, []Damage{{Amount: 3, Type: Click}}, 7, 1, 0},
+		{"hits in one update add up", nil, 0, []Damage{{Amount: 3, Type: Click}, {Amount: 2, Type: Impact}}, 5, 2, 0},
+		{"half resisted", map[DamageType]float32{Click: 0.5}, 0, []Damage{{Amount: 4, Type: Click}}, 8, 1, 0},
+		{"resistance only to its type", map[DamageType]float32{Click: 0.5}, 0, []Damage{{Amount: 4, Type: Impact}}, 6, 1, 0},
+		{"fully resisted", map[DamageType]float32{Impact: 1}, 0, []Damage{{Amount: 4, Type: Impact}}, 10, 0, 0},
+		{"nothing", nil, 0, []Damage{{Amount: 0}, {Amount: -2}}, 10, 0, 0},
+		{"invulnerable after the first hit", nil, 1, []Damage{{Amount: 3}, {Amount: 3}}, 7, 1, 0},
+		{"lethal", nil, 0, []Damage{{Amount: 15}}, -5, 1, 1},
+		{"dead entities take no more damage", nil, 0, []Damage{{Amount: 10}, {Amount: 5}}, 0, 1, 1},
+	}
+
+	for _, c := range cases {
+		s, damaged, deaths := newTestSystem()
+		component := NewComponent(10, 0, c.invulnerable)
+		if c.resistances != nil {
+			component.Resistances = c.resistances
+		}
+		e := addTestEntity(s, component)
+
+		for _, damage := range c.damage {
+			s.Damage(e.BasicEntity().ID(), damage)
+		}
+		s.Update(0.5)
+
+		if health := e.HealthComponent().Health; health != c.health {
+			t.Errorf("%s: expected health %v, got %v", c.name, c.health, health)
+		}
+		if len(*damaged) != c.damaged || len(*deaths) != c.deaths {
+			t.Errorf("%s: expected %d damaged and %d deaths, got %d and %d", c.name, c.damaged, c.deaths, len(*damaged), len(*deaths))
+		}
+		if dead := e.HealthComponent().Dead(); dead != (c.deaths > 0) {
+			t.Errorf("%s: expected dead to be %v", c.name, c.deaths > 0)
+		}
+	}
+}
+
+func TestSystemReportsDamageTaken(t *testing.T) {
+	s, damaged, _ := newTestSystem()
+	component := NewComponent(10, 0, 0)
+	component.Resistances[Click] = 0.75
+	e := addTestEntity(s, component)
+
+	s.Damage(e.BasicEntity().ID(), Damage{Amount: 4, Type: Click, Source: 7})
+	s.Update(0.5)
+	if len(*damaged) != 1 {
+		t.Fatalf("expected 1 damaged event, got %d", len(*damaged))
+	}
+	event := (*damaged)[0]
+	if event.Taken != 1 || event.Damage.Amount != 4 || event.Damage.Source != 7 || event.Entity.ID() != e.BasicEntity().ID() {
+		t.Errorf("expected 1 of the 4 damage from source 7 to be taken, got %+v", event)
+	}
+}
+
+func TestSystemIgnoresUnknownEntities(t *testing.T) {
+	s, damaged, _ := newTestSystem()
+	e := addTestEntity(s, NewComponent(10, 0, 0))
+	s.Remove(*e.BasicEntity())
+
+	s.Damage(e.BasicEntity().ID(), Damage{Amount: 4})
+	s.Damage(12345678, Damage{Amount: 4})
+	s.Update(0.5)
+	if e.HealthComponent().Health != 10 || len(*damaged) != 0 {
+		t.Errorf("expected removed and unknown entities to be ignored, got health %v and %d events", e.HealthComponent().Health, len(*damaged))
+	}
+}
+
+func TestSystemInvulnerabilityWearsOff(t *testing.T) {
+	s, damaged, _ := newTestSystem()
+	e := addTestEntity(s, NewComponent(10, 0, 1))
+	id := e.BasicEntity().ID()
+
+	steps := []struct {
+		dt           float32
+		health       float32
+		invulnerable bool
+	}{
+		{0.5, 9, true},  // hit, then half the invulnerability passes
+		{0.25, 9, true}, // still invulnerable, so this hit is ignored
+		{0.25, 9, false},
+		{0.5, 8, true}, // hit again once it's worn off
+	}
+	for i, step := range steps {
+		s.Damage(id, Damage{Amount: 1})
+		s.Update(step.dt)
+		if health := e.HealthComponent().Health; health != step.health || e.HealthComponent().Invulnerable() != step.invulnerable {
+			t.Errorf("step %d: expected health %v and invulnerable %v, got %v and %v", i, step.health, step.invulnerable, health, e.HealthComponent().Invulnerable())
+		}
+	}
+	if len(*damaged) != 2 {
+		t.Errorf("expected 2 damaged events, got %d", len(*damaged))
+	}
+}
+
+func TestSystemRegeneration(t *testing.T) {
+	s, _, _ := newTestSystem()
+	e := addTestEntity(s, NewComponent(10, 2, 0))
+	id := e.BasicEntity().ID()
+
+	s.Damage(id, Damage{Amount: 3})
+	s.Update(0.5) // 7, then 1 back
+	if health := e.HealthComponent().Health; health != 8 {
+		t.Errorf("expected health 8, got %v", health)
+	}
+	s.Update(10)
+	if health := e.HealthComponent().Health; health != 10 {
+		t.Errorf("expected regeneration to stop at max health, got %v", health)
+	}
+
+	s.Damage(id, Damage{Amount: 20})
+	s.Update(0.5)
+	s.Update(10)
+	if health := e.HealthComponent().Health; health != -10 {
+		t.Errorf("expected the dead not to regenerate, got %v", health)
+	}
+}
+
+func TestSystemPublishesDeathOnce(t *testing.T) {
+	s, _, deaths := newTestSystem()
+	e := addTestEntity(s, NewComponent(10, 5, 0))
+	id := e.BasicEntity().ID()
+
+	s.Damage(id, Damage{Amount: 10, Type: Impact})
+	s.Damage(id, Damage{Amount: 10, Type: Click})
+	s.Update(0.5)
+	s.Damage(id, Damage{Amount: 10})
+	s.Update(0.5)
+
+	if len(*deaths) != 1 {
+		t.Fatalf("expected 1 death, got %d", len(*deaths))
+	}
+	if death := (*deaths)[0]; death.Damage.Type != Impact || death.Entity.ID() != id {
+		t.Errorf("expected the entity to be killed by the impact, got %+v", death)
+	}
+}
+
+func TestSystemPaused(t *testing.T) {
+	s, damaged, _ := newTestSystem()
+	e := addTestEntity(s, NewComponent(10, 0, 0))
+
+	s.SetPaused(true)
+	s.Damage(e.BasicEntity().ID(), Damage{Amount: 4})
+	s.Update(0.5)
+	if e.HealthComponent().Health != 10 || len(*damaged) != 0 {
+		t.Errorf("expected no damage while paused, got health %v", e.HealthComponent().Health)
+	}
+
+	// damage dealt while paused is applied once it's unpaused
+	s.SetPaused(false)
+	s.Update(0.5)
+	if e.HealthComponent().Health != 6 {
+		t.Errorf("expected the queued damage to be applied, got health %v", e.HealthComponent().Health)
+	}
+}
+
+func TestComponentPercent(t *testing.T) {
+	cases := []struct {
+		health, max, percent float32
+	}{
+		{10, 10, 1},
+		{5, 10, 0.5},
+		{-5, 10, 0},
+		{15, 10, 1},
+		{5, 0, 0},
+	}
+	for _, c := range cases {
+		component := Component{Health: c.health, MaxHealth: c.max}
+		if percent := component.Percent(); percent != c.percent {
+			t.Errorf("%v of %v: expected %v, got %v", c.health, c.max, c.percent, percent)
+		}
+	}
+}
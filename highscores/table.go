@@ -0,0 +1,166 @@
+package highscores
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+	"github.com/pkg/errors"
+)
+
+// DefaultCapacity is the number of results a table keeps if no capacity is given
+const DefaultCapacity = 10
+
+// A Result is the outcome of a single session of the game
+type Result struct {
+	Score   int64     `json:"score"`
+	Kills   int       `json:"kills"`
+	Escaped int       `json:"escaped"`
+	Seconds float32   `json:"seconds"` // how long the session was played for
+	Seed    int64     `json:"seed"`
+	Scene   string    `json:"scene"` // the scene file the session was played on
+	Played  time.Time `json:"played"`
+}
+
+// A Table is a ranked list of the best results, highest score first
+type Table struct {
+	Results  []Result
+	Capacity int // the maximum number of results kept; lower ranked results are dropped
+}
+
+// NewTable constructs an empty table that keeps at most capacity results
+func NewTable(capacity int) *Table {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	return &Table{
+		Results:  make([]Result, 0, capacity),
+		Capacity: capacity,
+	}
+}
+
+// Insert ranks the result into the table, returning its rank starting at 1,
+// or 0 if it wasn't good enough to be kept
+func (t *Table) Insert(result Result) int {
+	// ties go to the earlier result, so the new one goes after every result that is at least as good
+	index := sort.Search(len(t.Results), func(i int) bool {
+		return t.Results[i].Score < result.Score
+	})
+	if index >= t.Capacity {
+		return 0
+	}
+
+	t.Results = append(t.Results, Result{})
+	copy(t.Results[index+1:], t.Results[index:])
+	t.Results[index] = result
+
+	if len(t.Results) > t.Capacity {
+		t.Results = t.Results[:t.Capacity]
+	}
+
+	return index + 1
+}
+
+// Load reads the table stored at the given path
+// A missing file is an empty table. Each result is stored on its own line,
+// and any line that can't be read is logged and skipped, so one corrupted result doesn't lose the rest
+func Load(path string, capacity int, log logging.Logger) (*Table, error) {
+	table := NewTable(capacity)
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return table, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open high score file")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		result := Result{}
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			log.Error("Skipping corrupt high score", logging.F{"path": path, "line": line, "error": err})
+			continue
+		}
+		table.Insert(result)
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Error("Stopped reading high score file early", logging.F{"path": path, "error": err, "results": len(table.Results)})
+	}
+
+	return table, nil
+}
+
+// Save writes the table to the given path
+// The table is written to a temporary file that then replaces the old one, so a crash
+// part way through never leaves a half written table behind
+func (t *Table) Save(path string) error {
+	temp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create temporary high score file")
+	}
+
+	// if anything fails, don't leave the temporary file lying around
+	defer os.Remove(temp.Name())
+
+	// temporary files are only readable by their owner, unlike the table written with os.Create before
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		return errors.Wrap(err, "Failed to set high score file permissions")
+	}
+
+	encoder := json.NewEncoder(temp)
+	for _, result := range t.Results {
+		if err := encoder.Encode(result); err != nil {
+			temp.Close()
+			return errors.Wrap(err, "Failed to write high score")
+		}
+	}
+
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return errors.Wrap(err, "Failed to flush high score file")
+	}
+	if err := temp.Close(); err != nil {
+		return errors.Wrap(err, "Failed to close temporary high score file")
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return errors.Wrap(err, "Failed to replace high score file")
+	}
+
+	return nil
+}
+
+// Lines returns a human readable row for each result, with a header row first
+func (t *Table) Lines() []string {
+	lines := make([]string, 0, len(t.Results)+1)
+	lines = append(lines, fmt.Sprintf("%4s   %10s   %5s   %7s   %8s   %-16s   %s", "Rank", "Score", "Kills", "Escaped", "Time(s)", "Played", "Scene"))
+	for i, r := range t.Results {
+		lines = append(lines, fmt.Sprintf("%4d   %10d   %5d   %7d   %8.1f   %-16s   %s", i+1, r.Score, r.Kills, r.Escaped, r.Seconds, r.Played.Local().Format("2006-01-02 15:04"), r.Scene))
+	}
+	return lines
+}
+
+// Write prints the table to the given writer, one row per line
+func (t *Table) Write(w io.Writer) error {
+	for _, line := range t.Lines() {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package highscores
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+)
+
+func newTestDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "highscores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// scores returns the score and seed of each result in the table, in rank order
+func scores(table *Table) [][2]int64 {
+	scores := make([][2]int64, len(table.Results))
+	for i, r := range table.Results {
+		scores[i] = [2]int64{r.Score, r.Seed}
+	}
+	return scores
+}
+
+func equalScores(a, b [][2]int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTableInsert(t *testing.T) {
+	cases := []struct {
+		name     string
+		capacity int
+		scores   []int64 // inserted in order, each with its index as its seed
+		ranks    []int
+		table    [][2]int64
+	}{
+		{"empty", 3, nil, nil, [][2]int64{}},
+		{"ranked highest first", 3, []int64{10, 30, 20}, []int{1, 1, 2}, [][2]int64{{30, 1}, {20, 2}, {10, 0}}},
+		{"ties go to the earlier result", 3, []int64{10, 10, 20, 10}, []int{1, 2, 1, 0}, [][2]int64{{20, 2}, {10, 0}, {10, 1}}},
+		{"lowest dropped past capacity", 2, []int64{10, 20, 30}, []int{1, 1, 1}, [][2]int64{{30, 2}, {20, 1}}},
+		{"not good enough past capacity", 2, []int64{20, 30, 10}, []int{1, 1, 0}, [][2]int64{{30, 1}, {20, 0}}},
+		{"default capacity", 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, [][2]int64{{11, 10}, {10, 9}, {9, 8}, {8, 7}, {7, 6}, {6, 5}, {5, 4}, {4, 3}, {3, 2}, {2, 1}}},
+	}
+
+	for _, c := range cases {
+		table := NewTable(c.capacity)
+		for i, score := range c.scores {
+			if rank := table.Insert(Result{Score: score, Seed: int64(i)}); rank != c.ranks[i] {
+				t.Errorf("%s: expected score %d to be ranked %d, got %d", c.name, score, c.ranks[i], rank)
+			}
+		}
+		if actual := scores(table); !equalScores(actual, c.table) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.table, actual)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	cases := []struct {
+		name     string
+		contents string
+		capacity int
+		table    [][2]int64
+	}{
+		{"empty file", "", 3, [][2]int64{}},
+		{"ranked on load", `{"score": 10, "seed": 1}` + "\n" + `{"score": 30, "seed": 2}` + "\n", 3, [][2]int64{{30, 2}, {10, 1}}},
+		{"blank lines skipped", "\n" + `{"score": 10, "seed": 1}` + "\n\n", 3, [][2]int64{{10, 1}}},
+		{"corrupt lines skipped", `{"score": 10, "seed": 1}` + "\n" + `{"score": 3` + "\n" + `not json` + "\n" + `{"score": 20, "seed": 3}` + "\n", 3, [][2]int64{{20, 3}, {10, 1}}},
+		{"capacity applied on load", `{"score": 10}` + "\n" + `{"score": 30}` + "\n" + `{"score": 20}` + "\n", 2, [][2]int64{{30, 0}, {20, 0}}},
+	}
+
+	for i, c := range cases {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		if err := ioutil.WriteFile(path, []byte(c.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		table, err := Load(path, c.capacity, logging.NewDefaultLogger(logging.INFO))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if actual := scores(table); !equalScores(actual, c.table) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.table, actual)
+		}
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	table, err := Load(filepath.Join(dir, "missing"), 3, logging.NewDefaultLogger(logging.INFO))
+	if err != nil {
+		t.Fatalf("expected a missing file to be an empty table, got %v", err)
+	}
+	if len(table.Results) != 0 || table.Capacity != 3 {
+		t.Errorf("expected an empty table of 3, got %d results of %d", len(table.Results), table.Capacity)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "scores")
+
+	played := time.Date(2018, 3, 14, 15, 9, 26, 0, time.UTC)
+	table := NewTable(3)
+	table.Insert(Result{Score: 200, Kills: 20, Escaped: 2, Seconds: 61.5, Seed: 7, Scene: "levels/one.json", Played: played})
+	table.Insert(Result{Score: 100, Kills: 10, Escaped: 5, Seconds: 30.25, Seed: 8, Scene: "default", Played: played.Add(time.Hour)})
+	if err := table.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path, 3, logging.NewDefaultLogger(logging.INFO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Results) != len(table.Results) {
+		t.Fatalf("expected %d results, got %d", len(table.Results), len(loaded.Results))
+	}
+	for i, expected := range table.Results {
+		actual := loaded.Results[i]
+		if !actual.Played.Equal(expected.Played) {
+			t.Errorf("result %d: expected to be played at %v, got %v", i, expected.Played, actual.Played)
+		}
+		actual.Played = expected.Played
+		if actual != expected {
+			t.Errorf("result %d: expected %+v, got %+v", i, expected, actual)
+		}
+	}
+
+	// saving again replaces the file rather than adding to it
+	table.Insert(Result{Score: 150})
+	if err := table.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = Load(path, 3, logging.NewDefaultLogger(logging.INFO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := scores(loaded); !equalScores(actual, [][2]int64{{200, 7}, {150, 0}, {100, 8}}) {
+		t.Errorf("expected the second save to replace the first, got %v", actual)
+	}
+}
+
+func TestSavePermissions(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "scores")
+
+	if err := NewTable(3).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0644 {
+		t.Errorf("expected the table to be saved with mode 0644, got %v", mode)
+	}
+
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the table in the directory, got %d files", len(files))
+	}
+}
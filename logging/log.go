@@ -16,6 +16,7 @@ const (
 const (
 	colorinfo  = "\x1b[37m"
 	colorerror = "\x1b[31m"
+	colorwarn  = "\x1b[33m"
 	colordebug = "\x1b[39m"
 	endcolor   = "\x1b[0m"
 )
@@ -30,6 +31,7 @@ func colorize(color, text string) string {
 // A Logger is a structered logger, printing a message and zero or more named fields
 type Logger interface {
 	Error(msg string, fields map[string]interface{})
+	Warn(msg string, fields map[string]interface{})
 	Info(msg string, fields map[string]interface{})
 	Debug(msg string, fields map[string]interface{})
 }
@@ -58,6 +60,10 @@ func (l *DefaultLogger) Error(msg string, fields map[string]interface{}) {
 	l.log(msg, fields, colorerror, "ERR")
 }
 
+func (l *DefaultLogger) Warn(msg string, fields map[string]interface{}) {
+	l.log(msg, fields, colorwarn, "WRN")
+}
+
 func (l *DefaultLogger) Info(msg string, fields map[string]interface{}) {
 	l.log(msg, fields, colorinfo, "INF")
 }
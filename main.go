@@ -1,14 +1,44 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"syscall"
+	"time"
 
 	"engo.io/engo"
+	"github.com/bcokert/engo-test/highscores"
 	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/metrics"
+	"github.com/bcokert/engo-test/scenes"
+	highscoresscene "github.com/bcokert/engo-test/scenes/highscores"
 	"github.com/bcokert/engo-test/scenes/owlclicker"
+	"github.com/bcokert/engo-test/scenes/results"
+	"github.com/bcokert/engo-test/scenes/title"
 )
 
 func main() {
+	sceneFile := flag.String("scene", "assets/scenes/owlclicker.json", "the scene file to load the owlclicker level from. Empty uses the default level")
+	highScoreFile := flag.String("highscores", "highscores.jsonl", "the file the high score table is kept in. Empty disables high scores")
+	dumpHighScores := flag.Bool("dump-highscores", false, "print the high score table and exit")
+	showHighScores := flag.Bool("show-highscores", false, "open the high score table instead of the game")
+	metricsFormat := flag.String("metrics-format", "text", "the format of the metrics files: text, json, csv or prometheus")
+	metricsInterval := flag.Duration("metrics-interval", 30*time.Second, "how often the metrics files are written while playing. 0 only writes them after each game")
+	metricsWindows := flag.Int("metrics-windows", 10, "how many of the most recent intervals' metrics files are kept")
+	traceEvents := flag.Int("trace-events", 0, "the number of most recent timed events to keep and write as a Chrome trace after each game. 0 disables tracing")
+	metricsAddr := flag.String("metrics-addr", "", "serve live metrics and pprof on this localhost address, like localhost:6060. Empty disables the server")
+	flag.Parse()
+
+	exporter, err := metrics.ExporterByName(*metricsFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *traceEvents > 0 {
+		metrics.SetRecorder(metrics.NewRecorder(*traceEvents))
+	}
+
 	options := engo.RunOptions{
 		Title:  "Owl Game",
 		Width:  800, // pixels
@@ -24,5 +54,52 @@ func main() {
 	}
 	logger := logging.NewDefaultLogger(logLevel)
 
-	engo.Run(options, &owlclicker.Scene{Log: logger})
+	if *dumpHighScores {
+		table, err := highscores.Load(*highScoreFile, highscores.DefaultCapacity, logger)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		table.Write(os.Stdout)
+		return
+	}
+
+	// metrics are flushed as the game runs, and when it panics or is interrupted, so they're never lost
+	flusher := metrics.NewFlusher(
+		fmt.Sprintf("functionmetrics/owlicker.%s", time.Now().Local().Format("2006-01-02-15-04-05")),
+		exporter,
+		*metricsInterval,
+		*metricsWindows,
+		logger)
+	flusher.FlushOnSignal(os.Interrupt, syscall.SIGTERM)
+	flusher.Start()
+	defer func() {
+		if err := flusher.Stop(); err != nil {
+			logger.Error("Failed to flush metrics", logging.F{"error": err, "path": flusher.Path()})
+		}
+	}()
+	defer flusher.Recover()
+
+	session := &scenes.Session{}
+	titleScene := &title.Scene{Log: logger, HighScores: *highScoreFile}
+	highScoresScene := &highscoresscene.Scene{Log: logger, File: *highScoreFile}
+	engo.RegisterScene(titleScene)
+	engo.RegisterScene(highScoresScene)
+	engo.RegisterScene(&owlclicker.Scene{Log: logger, File: *sceneFile, HighScores: *highScoreFile, Session: session, Metrics: flusher})
+	engo.RegisterScene(&results.Scene{Log: logger, Session: session})
+
+	if *showHighScores {
+		engo.Run(options, highScoresScene)
+		return
+	}
+
+	// the metrics are only served while the game is being played, not when just looking at high scores
+	if *metricsAddr != "" {
+		if err := metrics.Serve(*metricsAddr, logger); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	engo.Run(options, titleScene)
 }
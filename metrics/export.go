@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Report is a point in time summary of every metric in a registry, ready to be exported
+type Report struct {
+	Time    time.Time
+	Metrics []Metric // sorted by name
+	Values  []Value  // the counters, gauges and rates, sorted by name
+}
+
+// A Metric is the summary of a single aggregate. Every duration is in nanoseconds
+type Metric struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+	Sum   int64  `json:"sum_ns"`
+	Self  int64  `json:"self_ns"` // the part of the sum not spent in nested spans
+	Avg   int64  `json:"avg_ns"`
+	Min   int64  `json:"min_ns"`
+	Max   int64  `json:"max_ns"`
+	P50   int64  `json:"p50_ns"`
+	P90   int64  `json:"p90_ns"`
+	P99   int64  `json:"p99_ns"`
+	P999  int64  `json:"p999_ns"`
+}
+
+// NewReport summarizes the given aggregates, skipping any that haven't been called
+func NewReport(at time.Time, aggregates map[string]FunctionTimeAggregate) Report {
+	report := Report{Time: at, Metrics: make([]Metric, 0, len(aggregates))}
+	for name, aggregate := range aggregates {
+		aggregate := aggregate
+		if aggregate.Count == 0 {
+			continue
+		}
+		report.Metrics = append(report.Metrics, Metric{
+			Name:  name,
+			Count: aggregate.Count,
+			Sum:   aggregate.Sum,
+			Self:  aggregate.Self,
+			Avg:   int64(float64(aggregate.Sum) / float64(aggregate.Count)),
+			Min:   aggregate.Min,
+			Max:   aggregate.Max,
+			P50:   aggregate.Percentile(50),
+			P90:   aggregate.Percentile(90),
+			P99:   aggregate.Percentile(99),
+			P999:  aggregate.Percentile(99.9),
+		})
+	}
+	sort.Slice(report.Metrics, func(i, j int) bool { return report.Metrics[i].Name < report.Metrics[j].Name })
+	return report
+}
+
+// An Exporter writes reports in a particular format
+type Exporter interface {
+	Export(w io.Writer, report Report) error
+	Extension() string // the file extension for the format, without a dot
+}
+
+// Exporters are every built in exporter, by the name of their format
+var Exporters = map[string]Exporter{
+	"text":       TextExporter{},
+	"json":       JSONExporter{},
+	"csv":        CSVExporter{},
+	"prometheus": PrometheusExporter{},
+}
+
+// ExporterByName returns the built in exporter for the named format
+func ExporterByName(name string) (Exporter, error) {
+	exporter, ok := Exporters[name]
+	if !ok {
+		names := make([]string, 0, len(Exporters))
+		for name := range Exporters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("Unknown metrics format %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return exporter, nil
+}
+
+// The TextExporter writes a fixed width table for people to read, with the header repeated every 10 rows
+// Self is the average time per call that wasn't spent in nested spans
+type TextExporter struct{}
+
+func (e TextExporter) Extension() string {
+	return "metrics"
+}
+
+func (e TextExporter) Export(w io.Writer, report Report) error {
+	if _, err := fmt.Fprintf(w, "Registry output for %s\n", report.Time.Local().Format("2006-01-02 15:04:05")); err != nil {
+		return err
+	}
+
+	// call paths can be much longer than function names, so the name column fits the longest
+	width := 30
+	for _, m := range report.Metrics {
+		if len(m.Name) > width {
+			width = len(m.Name)
+		}
+	}
+
+	for i, m := range report.Metrics {
+		if i%10 == 0 {
+			if _, err := fmt.Fprintf(w, "\n%*s   %10s   %10s   %10s   %10s   %10s   %10s   %10s   %10s   %10s\n\n", width, "Metric Name", "Count(##)", "Avg(ns)", "Self(ns)", "Max(ns)", "Min(ns)", "p50(ns)", "p90(ns)", "p99(ns)", "p99.9(ns)"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%*s   %10d   %10d   %10d   %10d   %10d   %10d   %10d   %10d   %10d\n", width, m.Name, m.Count, m.Avg, m.Self/m.Count, m.Max, m.Min, m.P50, m.P90, m.P99, m.P999); err != nil {
+			return err
+		}
+	}
+
+	if len(report.Values) == 0 {
+		return nil
+	}
+	valueWidth := 30
+	for _, v := range report.Values {
+		if len(v.Name) > valueWidth {
+			valueWidth = len(v.Name)
+		}
+	}
+	if _, err := fmt.Fprintf(w, "\n%*s   %10s   %14s   %14s\n\n", valueWidth, "Value Name", "Kind", "Value", "Peak"); err != nil {
+		return err
+	}
+	for _, v := range report.Values {
+		if _, err := fmt.Fprintf(w, "%*s   %10s   %14s   %14s\n", valueWidth, v.Name, v.Kind, formatValue(v.Value), formatValue(v.Peak)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The JSONExporter writes a single JSON object, with the time in RFC 3339 and every duration in nanoseconds
+type JSONExporter struct{}
+
+func (e JSONExporter) Extension() string {
+	return "json"
+}
+
+func (e JSONExporter) Export(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Time    string   `json:"time"`
+		Metrics []Metric `json:"metrics"`
+		Values  []Value  `json:"values,omitempty"`
+	}{
+		Time:    report.Time.Format(time.RFC3339Nano),
+		Metrics: report.Metrics,
+		Values:  report.Values,
+	})
+}
+
+// csvTimingKind is the kind of the CSVExporter's rows for timed functions, next to the kinds of Value
+const csvTimingKind = "timing"
+
+// csvHeader is the header row of the CSVExporter, which uses the same names as the JSONExporter
+var csvHeader = []string{"name", "kind", "count", "sum_ns", "self_ns", "avg_ns", "min_ns", "max_ns", "p50_ns", "p90_ns", "p99_ns", "p999_ns", "value", "peak"}
+
+// The CSVExporter writes a header row followed by a row per metric, with every duration in nanoseconds,
+// then a row per counter, gauge and rate. The kind column tells them apart, and columns that don't apply to a row are empty
+type CSVExporter struct{}
+
+func (e CSVExporter) Extension() string {
+	return "csv"
+}
+
+func (e CSVExporter) Export(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, m := range report.Metrics {
+		row := make([]string, 0, len(csvHeader))
+		row = append(row, m.Name, csvTimingKind)
+		for _, value := range []int64{m.Count, m.Sum, m.Self, m.Avg, m.Min, m.Max, m.P50, m.P90, m.P99, m.P999} {
+			row = append(row, strconv.FormatInt(value, 10))
+		}
+		row = append(row, "", "")
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range report.Values {
+		row := make([]string, len(csvHeader))
+		row[0], row[1] = v.Name, v.Kind
+		row[len(row)-2] = formatValue(v.Value)
+		if v.Kind != CounterKind {
+			row[len(row)-1] = formatValue(v.Peak)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// The PrometheusExporter writes the Prometheus text exposition format
+// Each metric is a summary of function durations in seconds, labelled by function or call path,
+// plus a counter of the time not spent in nested spans and gauges of the min and max
+// Counters, gauges and rates are labelled by name, with the gauges' and rates' peaks as gauges of their own
+type PrometheusExporter struct{}
+
+func (e PrometheusExporter) Extension() string {
+	return "prom"
+}
+
+func (e PrometheusExporter) Export(w io.Writer, report Report) error {
+	lines := []string{
+		"# HELP function_duration_seconds How long each timed function took.",
+		"# TYPE function_duration_seconds summary",
+	}
+	for _, m := range report.Metrics {
+		label := prometheusLabel(m.Name)
+		lines = append(lines,
+			fmt.Sprintf("function_duration_seconds{function=%s,quantile=\"0.5\"} %s", label, seconds(m.P50)),
+			fmt.Sprintf("function_duration_seconds{function=%s,quantile=\"0.9\"} %s", label, seconds(m.P90)),
+			fmt.Sprintf("function_duration_seconds{function=%s,quantile=\"0.99\"} %s", label, seconds(m.P99)),
+			fmt.Sprintf("function_duration_seconds{function=%s,quantile=\"0.999\"} %s", label, seconds(m.P999)),
+			fmt.Sprintf("function_duration_seconds_sum{function=%s} %s", label, seconds(m.Sum)),
+			fmt.Sprintf("function_duration_seconds_count{function=%s} %d", label, m.Count),
+		)
+	}
+
+	lines = append(lines,
+		"# HELP function_self_seconds_total The time each timed function spent outside of nested spans.",
+		"# TYPE function_self_seconds_total counter",
+	)
+	for _, m := range report.Metrics {
+		lines = append(lines, fmt.Sprintf("function_self_seconds_total{function=%s} %s", prometheusLabel(m.Name), seconds(m.Self)))
+	}
+
+	lines = append(lines,
+		"# HELP function_duration_min_seconds The shortest time each timed function took.",
+		"# TYPE function_duration_min_seconds gauge",
+	)
+	for _, m := range report.Metrics {
+		lines = append(lines, fmt.Sprintf("function_duration_min_seconds{function=%s} %s", prometheusLabel(m.Name), seconds(m.Min)))
+	}
+
+	lines = append(lines,
+		"# HELP function_duration_max_seconds The longest time each timed function took.",
+		"# TYPE function_duration_max_seconds gauge",
+	)
+	for _, m := range report.Metrics {
+		lines = append(lines, fmt.Sprintf("function_duration_max_seconds{function=%s} %s", prometheusLabel(m.Name), seconds(m.Max)))
+	}
+
+	for _, family := range []struct {
+		kind, name, peak, help, peakHelp, kindType string
+	}{
+		{CounterKind, "metric_count_total", "", "The total of each counter.", "", "counter"},
+		{GaugeKind, "metric_gauge", "metric_gauge_peak", "The latest value of each gauge.", "The highest value of each gauge.", "gauge"},
+		{RateKind, "metric_rate_per_second", "metric_rate_peak_per_second", "How often each rated event happened on average.", "The most each rated event happened in a second.", "gauge"},
+	} {
+		values := make([]Value, 0, len(report.Values))
+		for _, v := range report.Values {
+			if v.Kind == family.kind {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		lines = append(lines, "# HELP "+family.name+" "+family.help, "# TYPE "+family.name+" "+family.kindType)
+		for _, v := range values {
+			lines = append(lines, fmt.Sprintf("%s{name=%s} %s", family.name, prometheusLabel(v.Name), formatValue(v.Value)))
+		}
+		if family.peak == "" {
+			continue
+		}
+		lines = append(lines, "# HELP "+family.peak+" "+family.peakHelp, "# TYPE "+family.peak+" gauge")
+		for _, v := range values {
+			lines = append(lines, fmt.Sprintf("%s{name=%s} %s", family.peak, prometheusLabel(v.Name), formatValue(v.Peak)))
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// prometheusLabel quotes a label value, escaping the characters the exposition format requires
+func prometheusLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return `"` + value + `"`
+}
+
+// formatValue formats a counter, gauge or rate as briefly as it can be without losing precision
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// seconds formats nanoseconds as seconds
+func seconds(nanoseconds int64) string {
+	return strconv.FormatFloat(float64(nanoseconds)/1e9, 'g', -1, 64)
+}
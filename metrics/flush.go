@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+	"github.com/pkg/errors"
+)
+
+// A Flusher writes a registry's metrics to files while the program runs, so that they aren't lost if it crashes or is killed
+// Every flush overwrites one file with the whole run so far, and writes another with only what happened since the
+// previous flush, keeping the most recent few of those. Every file is written atomically, so none is ever left half written
+type Flusher struct {
+	registry *FunctionTimeRegistry
+	base     string // the path of every file, without the extension
+	exporter Exporter
+	interval time.Duration
+	keep     int
+	log      logging.Logger
+
+	lock       sync.Mutex
+	window     int // the number of the next window
+	last       time.Time
+	aggregates map[string]FunctionTimeAggregate // the aggregates as of the last flush, to work out each window from
+	totals     map[string]int64                 // the counter and rate totals as of the last flush
+	generation int64                            // the registry's generation as of the last flush
+	stop       chan struct{}
+	signals    chan os.Signal
+	stopped    bool
+}
+
+// NewFlusher constructs a flusher that writes this registry's metrics to files starting with base, and ending with
+// the exporter's extension. Every interval it flushes on its own, once Start is called, and it keeps the files of
+// the last keep windows. The first window starts now
+func (r *FunctionTimeRegistry) NewFlusher(base string, exporter Exporter, interval time.Duration, keep int, log logging.Logger) *Flusher {
+	if keep < 1 {
+		keep = 1
+	}
+	f := &Flusher{
+		registry: r,
+		base:     base,
+		exporter: exporter,
+		interval: interval,
+		keep:     keep,
+		log:      log,
+		last:     time.Now(),
+	}
+	f.aggregates, f.totals, _, f.generation = r.state(f.last)
+	return f
+}
+
+// NewFlusher uses the default registry, see func (r *FunctionTimeRegistry) NewFlusher
+func NewFlusher(base string, exporter Exporter, interval time.Duration, keep int, log logging.Logger) *Flusher {
+	return defaultRegistry.NewFlusher(base, exporter, interval, keep, log)
+}
+
+// Path returns the path of the file with the whole run in it
+func (f *Flusher) Path() string {
+	return fmt.Sprintf("%s.%s", f.base, f.exporter.Extension())
+}
+
+// windowPath returns the path of the file with the given window in it
+func (f *Flusher) windowPath(window int) string {
+	return fmt.Sprintf("%s.window-%d.%s", f.base, window, f.exporter.Extension())
+}
+
+// Flush writes the whole run so far, and the window since the last flush. Nothing is written until something is collected
+func (f *Flusher) Flush() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now()
+	aggregates, totals, values, generation := f.registry.state(now)
+	whole := NewReport(now, aggregates)
+	whole.Values = values
+	if len(whole.Metrics) == 0 && len(whole.Values) == 0 {
+		return nil
+	}
+
+	// after a reset, everything in the registry is new, so the window starts from nothing
+	// The part of the window before the reset is lost along with the rest of the registry
+	earlierAggregates, earlierTotals := f.aggregates, f.totals
+	if generation != f.generation {
+		earlierAggregates, earlierTotals = map[string]FunctionTimeAggregate{}, map[string]int64{}
+	}
+
+	window := make(map[string]FunctionTimeAggregate, len(aggregates))
+	for name, aggregate := range aggregates {
+		window[name] = aggregate.Since(earlierAggregates[name])
+	}
+	windowReport := NewReport(now, window)
+	windowReport.Values = windowValues(whole.Values, totals, earlierTotals, now.Sub(f.last).Seconds())
+
+	if err := writeAtomically(f.Path(), func(w io.Writer) error { return f.exporter.Export(w, whole) }); err != nil {
+		return errors.Wrap(err, "Failed to write metrics")
+	}
+	if err := writeAtomically(f.windowPath(f.window), func(w io.Writer) error { return f.exporter.Export(w, windowReport) }); err != nil {
+		return errors.Wrap(err, "Failed to write metrics window")
+	}
+
+	// the window only ends once it's been written, so if it can't be, the next flush's window covers it too
+	f.aggregates, f.totals, f.generation, f.last = aggregates, totals, generation, now
+
+	if f.window >= f.keep {
+		if err := os.Remove(f.windowPath(f.window - f.keep)); err != nil && !os.IsNotExist(err) {
+			f.log.Error("Failed to remove old metrics window", logging.F{"error": err, "path": f.windowPath(f.window - f.keep)})
+		}
+	}
+	f.window++
+	return nil
+}
+
+// Start flushes every interval in the background until Stop is called. It does nothing if the interval isn't positive
+func (f *Flusher) Start() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.interval <= 0 || f.stop != nil {
+		return
+	}
+	f.stop = make(chan struct{})
+	go f.run(f.stop)
+}
+
+func (f *Flusher) run(stop chan struct{}) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.Flush(); err != nil {
+				f.log.Error("Failed to flush metrics", logging.F{"error": err, "path": f.Path()})
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Stop stops flushing in the background and on signals, then flushes one last time
+// Only the first call does anything, so it's safe to both defer it and call it when something goes wrong
+func (f *Flusher) Stop() error {
+	f.lock.Lock()
+	if f.stopped {
+		f.lock.Unlock()
+		return nil
+	}
+	f.stopped = true
+	if f.stop != nil {
+		close(f.stop)
+		f.stop = nil
+	}
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		f.signals = nil
+	}
+	f.lock.Unlock()
+
+	return f.Flush()
+}
+
+// FlushOnSignal flushes and exits when any of the given signals are received, like os.Interrupt
+func (f *Flusher) FlushOnSignal(signals ...os.Signal) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+	}
+	f.signals = make(chan os.Signal, 1)
+	signal.Notify(f.signals, signals...)
+
+	go func(received chan os.Signal) {
+		sig, ok := <-received
+		if !ok {
+			return
+		}
+		f.log.Info("Flushing metrics before exiting", logging.F{"signal": sig, "path": f.Path()})
+		if err := f.Flush(); err != nil {
+			f.log.Error("Failed to flush metrics", logging.F{"error": err, "path": f.Path()})
+		}
+		os.Exit(1)
+	}(f.signals)
+}
+
+// Recover stops the flusher if the goroutine is panicking, which flushes one last time, then carries on panicking
+// It must be deferred, like:
+// defer flusher.Recover()
+func (f *Flusher) Recover() {
+	if p := recover(); p != nil {
+		f.log.Error("Flushing metrics after a panic", logging.F{"panic": p, "path": f.Path()})
+		if err := f.Stop(); err != nil {
+			f.log.Error("Failed to flush metrics", logging.F{"error": err, "path": f.Path()})
+		}
+		panic(p)
+	}
+}
+
+// state reads everything a flush needs from the registry, all from the same generation
+// If the registry is reset partway through, it starts over
+func (r *FunctionTimeRegistry) state(now time.Time) (map[string]FunctionTimeAggregate, map[string]int64, []Value, int64) {
+	for {
+		generation := r.Generation()
+		aggregates := r.Snapshot()
+		totals := r.totals()
+		values := r.Values(now)
+		if r.Generation() == generation {
+			return aggregates, totals, values, generation
+		}
+	}
+}
+
+// writeAtomically writes a file by writing a temporary file next to it and renaming it into place,
+// so the file is either left as it was or completely written, even if the program dies partway through
+func writeAtomically(path string, write func(w io.Writer) error) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create temporary file")
+	}
+
+	// temporary files are only readable by their owner, unlike the files written with os.Create before
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return errors.Wrap(err, "Failed to set temporary file permissions")
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return errors.Wrap(err, "Failed to close temporary file")
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return errors.Wrap(err, "Failed to move temporary file into place")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+)
+
+func newTestFlusher(t *testing.T, r *FunctionTimeRegistry) (*Flusher, func()) {
+	dir, err := ioutil.TempDir("", "flush")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := r.NewFlusher(filepath.Join(dir, "run"), JSONExporter{}, 0, 10, logging.NewDefaultLogger(logging.INFO))
+	return f, func() { os.RemoveAll(dir) }
+}
+
+func readReport(t *testing.T, path string) Report {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	report, err := ParseReport(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func metricNamed(report Report, name string) Metric {
+	for _, m := range report.Metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+	return Metric{}
+}
+
+func TestFlushWindows(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	f, cleanup := newTestFlusher(t, r)
+	defer cleanup()
+
+	for i := 0; i < 10; i++ {
+		r.Timed("function", time.Now().Add(-time.Microsecond))
+	}
+	r.Count("counter", 3)
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		r.Timed("function", time.Now())
+	}
+	r.Count("counter", 2)
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := metricNamed(readReport(t, f.Path()), "function"); m.Count != 15 {
+		t.Errorf("expected the whole run to have 15 calls, got %d", m.Count)
+	}
+	window := readReport(t, f.windowPath(1))
+	if m := metricNamed(window, "function"); m.Count != 5 {
+		t.Errorf("expected the second window to have 5 calls, got %d", m.Count)
+	}
+	if len(window.Values) != 1 || window.Values[0].Value != 2 {
+		t.Errorf("expected the second window to count 2, got %+v", window.Values)
+	}
+}
+
+func TestFlushAfterReset(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	f, cleanup := newTestFlusher(t, r)
+	defer cleanup()
+
+	for i := 0; i < 10; i++ {
+		r.Timed("function", time.Now().Add(-1000*time.Nanosecond))
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// more calls after the reset than before it, so the reset can't be spotted by the count going down
+	r.Reset()
+	for i := 0; i < 20; i++ {
+		r.Timed("function", time.Now())
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := metricNamed(readReport(t, f.windowPath(1)), "function")
+	if m.Count != 20 || m.Sum < 0 || m.Avg < 0 {
+		t.Errorf("expected the window after the reset to have 20 calls and a positive sum, got %+v", m)
+	}
+}
+
+func TestFlushKeepsBaselineWhenWriteFails(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	f, cleanup := newTestFlusher(t, r)
+	defer cleanup()
+
+	r.Timed("function", time.Now())
+	base := f.base
+	f.base = filepath.Join(base, "missing", "run")
+	if err := f.Flush(); err == nil {
+		t.Fatalf("expected writing to a missing directory to fail")
+	}
+
+	f.base = base
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if m := metricNamed(readReport(t, f.windowPath(0)), "function"); m.Count != 1 {
+		t.Errorf("expected the call from the failed flush to be in the next window, got %d calls", m.Count)
+	}
+}
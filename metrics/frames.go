@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+)
+
+// A FrameBudget describes how long each frame may take, and when frames over it are warned about
+type FrameBudget struct {
+	Budget      float32 `json:"budget"`      // milliseconds each frame may take, like 16.6 for 60 frames per second
+	Consecutive int     `json:"consecutive"` // how many frames in a row must be over budget before warning
+	Cooldown    float32 `json:"cooldown"`    // seconds after a warning during which no more warnings are logged
+	Breakdown   int     `json:"breakdown"`   // how many of the frame's slowest functions each warning lists
+}
+
+// DefaultFrameBudget returns a budget for 60 frames per second
+func DefaultFrameBudget() FrameBudget {
+	return FrameBudget{
+		Budget:      16.6,
+		Consecutive: 3,
+		Cooldown:    5,
+		Breakdown:   5,
+	}
+}
+
+// Validate returns a description of every problem in the budget, or nothing if it is legal
+func (b FrameBudget) Validate() []string {
+	problems := []string{}
+	if b.Budget <= 0 {
+		problems = append(problems, fmt.Sprintf("frameBudget budget must be positive, got %v", b.Budget))
+	}
+	if b.Consecutive < 1 {
+		problems = append(problems, fmt.Sprintf("frameBudget consecutive must be at least 1, got %d", b.Consecutive))
+	}
+	if b.Cooldown < 0 {
+		problems = append(problems, fmt.Sprintf("frameBudget cooldown must not be negative, got %v", b.Cooldown))
+	}
+	if b.Breakdown < 0 {
+		problems = append(problems, fmt.Sprintf("frameBudget breakdown must not be negative, got %d", b.Breakdown))
+	}
+	return problems
+}
+
+// A FrameMonitor times each frame against a budget, counting the frames that go over it
+// While it's attached to a registry, every aggregate adds up its self time since the frame began, so that
+// a frame over budget can be blamed on the functions that took the longest during it. That's a single atomic add
+// per timed call, so timing stays cheap; the monitor only walks the aggregates once per frame
+type FrameMonitor struct {
+	budget      FrameBudget
+	log         logging.Logger
+	lock        sync.Mutex
+	registry    *FunctionTimeRegistry // the registry the monitor is attached to, if any
+	open        bool
+	start       time.Time
+	frames      int64
+	overBudget  int64
+	consecutive int
+	lastWarning time.Time
+}
+
+// A FrameTime is the time spent in a single function during a frame
+type FrameTime struct {
+	Name string
+	Self int64 // nanoseconds
+}
+
+// NewFrameMonitor constructs a monitor that warns about frames over the given budget
+// It only sees timed functions once it's attached to a registry with SetFrameMonitor
+func NewFrameMonitor(budget FrameBudget, log logging.Logger) *FrameMonitor {
+	return &FrameMonitor{
+		budget: budget,
+		log:    log,
+	}
+}
+
+// BeginFrame starts timing a frame. Anything timed before the frame ends is attributed to it
+func (m *FrameMonitor) BeginFrame() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.registry != nil {
+		m.registry.clearFrameTimes()
+	}
+	m.open = true
+	m.start = time.Now()
+}
+
+// EndFrame stops timing the open frame, and logs a warning if enough frames in a row have gone over budget
+// It returns how long the frame took
+func (m *FrameMonitor) EndFrame() time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.open {
+		return 0
+	}
+	m.open = false
+	now := time.Now()
+	duration := now.Sub(m.start)
+	m.frames++
+
+	budget := time.Duration(m.budget.Budget * float32(time.Millisecond))
+	if duration <= budget {
+		m.consecutive = 0
+		return duration
+	}
+	m.overBudget++
+	m.consecutive++
+
+	cooldown := time.Duration(m.budget.Cooldown * float32(time.Second))
+	if m.consecutive < m.budget.Consecutive || (!m.lastWarning.IsZero() && now.Sub(m.lastWarning) < cooldown) {
+		return duration
+	}
+	m.lastWarning = now
+
+	slowest, timed := m.slowest(m.budget.Breakdown)
+	breakdown := make([]string, 0, len(slowest)+1)
+	for _, f := range slowest {
+		breakdown = append(breakdown, fmt.Sprintf("%s=%s", f.Name, formatMs(f.Self)))
+	}
+	if untimed := duration.Nanoseconds() - timed; untimed > 0 {
+		breakdown = append(breakdown, fmt.Sprintf("untimed=%s", formatMs(untimed)))
+	}
+	m.log.Warn("Frame over budget", logging.F{
+		"frame":       formatMs(duration.Nanoseconds()),
+		"budget":      formatMs(budget.Nanoseconds()),
+		"consecutive": m.consecutive,
+		"overBudget":  fmt.Sprintf("%d/%d", m.overBudget, m.frames),
+		"slowest":     strings.Join(breakdown, " "),
+	})
+	return duration
+}
+
+// slowest returns at most n of the functions with the most self time in the frame, slowest first,
+// along with the total self time of every function. The lock must be held
+func (m *FrameMonitor) slowest(n int) ([]FrameTime, int64) {
+	if m.registry == nil {
+		return nil, 0
+	}
+	times := m.registry.frameTimes()
+	var total int64
+	for _, f := range times {
+		total += f.Self
+	}
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].Self != times[j].Self {
+			return times[i].Self > times[j].Self
+		}
+		return times[i].Name < times[j].Name
+	})
+	if len(times) > n {
+		times = times[:n]
+	}
+	return times, total
+}
+
+// Frames returns how many frames have been timed, and how many of them were over budget
+func (m *FrameMonitor) Frames() (frames, overBudget int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.frames, m.overBudget
+}
+
+// SetFrameMonitor attributes everything timed in this registry to the given monitor's open frame, or stops if it is nil
+// A monitor should only be attached to one registry at a time
+func (r *FunctionTimeRegistry) SetFrameMonitor(monitor *FrameMonitor) {
+	if monitor != nil {
+		monitor.lock.Lock()
+		monitor.registry = r
+		monitor.lock.Unlock()
+	}
+	r.monitor.Store(monitorBox{monitor})
+}
+
+// SetFrameMonitor uses the default registry, see func (r *FunctionTimeRegistry) SetFrameMonitor
+func SetFrameMonitor(monitor *FrameMonitor) {
+	defaultRegistry.SetFrameMonitor(monitor)
+}
+
+// frameMonitor returns the monitor attached to this registry, or nil if there is none
+func (r *FunctionTimeRegistry) frameMonitor() *FrameMonitor {
+	box, _ := r.monitor.Load().(monitorBox)
+	return box.monitor
+}
+
+// clearFrameTimes starts every aggregate's frame time over from zero, for a new frame
+func (r *FunctionTimeRegistry) clearFrameTimes() {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, a := range r.aggregates {
+		atomic.StoreInt64(&a.frameSelf, 0)
+	}
+}
+
+// frameTimes returns the self time of every function timed since the frame began
+func (r *FunctionTimeRegistry) frameTimes() []FrameTime {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	times := make([]FrameTime, 0, len(r.aggregates))
+	for name, a := range r.aggregates {
+		if self := atomic.LoadInt64(&a.frameSelf); self > 0 {
+			times = append(times, FrameTime{Name: name, Self: self})
+		}
+	}
+	return times
+}
+
+// monitorBox lets a nil monitor be stored in an atomic.Value, which can't store nil itself
+type monitorBox struct {
+	monitor *FrameMonitor
+}
+
+// formatMs formats nanoseconds as milliseconds, which is easier to compare against a frame budget
+func formatMs(ns int64) string {
+	return fmt.Sprintf("%.2fms", float64(ns)/float64(time.Millisecond))
+}
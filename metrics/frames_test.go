@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+)
+
+func TestFrameMonitorBlamesSlowestFunctions(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	monitor := NewFrameMonitor(DefaultFrameBudget(), logging.NewDefaultLogger(logging.INFO))
+	r.SetFrameMonitor(monitor)
+
+	// time from before the frame began isn't part of it
+	r.Timed("before", time.Now().Add(-time.Hour))
+
+	monitor.BeginFrame()
+	r.Timed("slow", time.Now().Add(-2*time.Millisecond))
+	r.Timed("slow", time.Now().Add(-2*time.Millisecond))
+	r.Timed("fast", time.Now().Add(-time.Millisecond))
+	tracer := r.NewTracer()
+	span := tracer.Start("outer")
+	tracer.End(tracer.Start("inner"))
+	tracer.End(span)
+
+	monitor.lock.Lock()
+	slowest, total := monitor.slowest(2)
+	monitor.lock.Unlock()
+	if len(slowest) != 2 || slowest[0].Name != "slow" || slowest[1].Name != "fast" {
+		t.Fatalf("expected slow then fast, got %+v", slowest)
+	}
+	if slowest[0].Self < int64(4*time.Millisecond) || total < int64(5*time.Millisecond) || total > int64(time.Minute) {
+		t.Errorf("expected slow to take at least 4ms of a total over 5ms, got %+v and %d", slowest, total)
+	}
+	monitor.EndFrame()
+
+	monitor.BeginFrame()
+	monitor.lock.Lock()
+	slowest, total = monitor.slowest(5)
+	monitor.lock.Unlock()
+	if len(slowest) != 0 || total != 0 {
+		t.Errorf("expected a new frame to start with nothing, got %+v", slowest)
+	}
+}
+
+func TestFrameMonitorDetached(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	monitor := NewFrameMonitor(DefaultFrameBudget(), logging.NewDefaultLogger(logging.INFO))
+	r.SetFrameMonitor(monitor)
+	r.SetFrameMonitor(nil)
+
+	monitor.BeginFrame()
+	r.Timed("function", time.Now().Add(-time.Millisecond))
+	monitor.lock.Lock()
+	slowest, _ := monitor.slowest(5)
+	monitor.lock.Unlock()
+	if len(slowest) != 0 {
+		t.Errorf("expected nothing to be attributed once detached, got %+v", slowest)
+	}
+	monitor.EndFrame()
+	if frames, _ := monitor.Frames(); frames != 1 {
+		t.Errorf("expected 1 frame, got %d", frames)
+	}
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+const (
+	// subBucketBits is how many bits of each value, below its highest set bit, decide its bucket
+	// 3 bits splits every power of 2 into 8 buckets, so every value is counted to within 1/8th of itself
+	subBucketBits = 3
+	subBuckets    = 1 << subBucketBits
+
+	// histogramBuckets covers every non-negative int64: the values below subBuckets exactly, then subBuckets
+	// buckets for each power of 2 above that
+	histogramBuckets = (63-subBucketBits)*subBuckets + subBuckets
+)
+
+// A Histogram counts durations in log-scaled buckets, which keeps the same relative precision for
+// durations of a microsecond and of a second in a fixed amount of memory
+// Recording a duration never allocates, and is safe to do from many goroutines at once
+type Histogram struct {
+	counts [histogramBuckets]int64
+}
+
+// Record counts a single value. Negative values are counted as 0
+func (h *Histogram) Record(value int64) {
+	atomic.AddInt64(&h.counts[bucketOf(value)], 1)
+}
+
+// snapshot copies the histogram's counts, reading each one atomically
+func (h *Histogram) snapshot() Histogram {
+	var copy Histogram
+	for i := range h.counts {
+		copy.counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	return copy
+}
+
+// since returns the counts recorded after the earlier snapshot of this histogram was taken
+func (h *Histogram) since(earlier *Histogram) Histogram {
+	var window Histogram
+	for i := range h.counts {
+		window.counts[i] = h.counts[i] - earlier.counts[i]
+	}
+	return window
+}
+
+// Min returns a value no more than the smallest recorded value, the bottom of the lowest bucket used, or 0 if there are none
+func (h *Histogram) Min() int64 {
+	for i, c := range h.counts {
+		if c > 0 {
+			if i == 0 {
+				return 0
+			}
+			return bucketTop(i-1) + 1
+		}
+	}
+	return 0
+}
+
+// Count returns the number of values recorded
+func (h *Histogram) Count() int64 {
+	count := int64(0)
+	for _, c := range h.counts {
+		count += c
+	}
+	return count
+}
+
+// Percentile returns a value that at least p percent of the recorded values are less than or equal to,
+// for p between 0 and 100. It is the top of the bucket the percentile falls in, so it is at most 1/8th too high
+func (h *Histogram) Percentile(p float64) int64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+
+	target := int64(p / 100 * float64(total))
+	if float64(target) < p/100*float64(total) {
+		target++
+	}
+	if target < 1 {
+		target = 1
+	}
+
+	seen := int64(0)
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return bucketTop(i)
+		}
+	}
+	return bucketTop(histogramBuckets - 1)
+}
+
+// bucketOf returns the index of the bucket a value is counted in
+func bucketOf(value int64) int {
+	if value < subBuckets {
+		if value < 0 {
+			return 0
+		}
+		return int(value)
+	}
+
+	exponent := bits.Len64(uint64(value)) - 1
+	sub := int(value>>uint(exponent-subBucketBits)) & (subBuckets - 1)
+	return (exponent-subBucketBits+1)*subBuckets + sub
+}
+
+// bucketTop returns the largest value counted in the bucket with the given index
+func bucketTop(index int) int64 {
+	if index < subBuckets {
+		return int64(index)
+	}
+
+	exponent := uint(index/subBuckets + subBucketBits - 1)
+	sub := int64(index % subBuckets)
+	bottom := (subBuckets + sub) << (exponent - subBucketBits)
+	return bottom + int64(1)<<(exponent-subBucketBits) - 1
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/bcokert/engo-test/logging"
+	"github.com/pkg/errors"
+)
+
+// Handler serves the registry's current metrics while the game is running, in the Prometheus format at /metrics
+// and in the JSONExporter's format at /metrics.json. POSTing to /reset forgets every metric, so the next ones
+// only cover what happens afterwards, and the runtime profiles from net/http/pprof are at /debug/pprof/
+func (r *FunctionTimeRegistry) Handler(log logging.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r.exportHandler(PrometheusExporter{}, "text/plain; version=0.0.4", log))
+	mux.Handle("/metrics.json", r.exportHandler(JSONExporter{}, "application/json", log))
+	mux.HandleFunc("/reset", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Resetting metrics requires a POST", http.StatusMethodNotAllowed)
+			return
+		}
+		r.Reset()
+		log.Info("Reset metrics", logging.F{"remote": req.RemoteAddr})
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// registered by hand, since importing pprof only adds them to the default mux
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// exportHandler serves the current report in the given format
+func (r *FunctionTimeRegistry) exportHandler(exporter Exporter, contentType string, log logging.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if err := exporter.Export(w, r.CurrentReport()); err != nil {
+			log.Error("Failed to serve metrics", logging.F{"error": err, "path": req.URL.Path})
+		}
+	})
+}
+
+// Serve starts serving the registry's Handler on the given address in the background, until the program exits
+// Metrics and profiles shouldn't be visible to anyone else on the network, so the address must be on localhost
+func (r *FunctionTimeRegistry) Serve(addr string, log logging.Logger) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.Wrap(err, "Invalid metrics server address")
+	}
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return fmt.Errorf("The metrics server must listen on localhost, got %q", addr)
+		}
+	}
+
+	// listening before returning means a port that's already in use is reported to the caller
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "Failed to start metrics server")
+	}
+
+	log.Info("Serving metrics", logging.F{"url": "http://" + listener.Addr().String() + "/metrics"})
+	go func() {
+		if err := http.Serve(listener, r.Handler(log)); err != nil {
+			log.Error("Metrics server stopped", logging.F{"error": err})
+		}
+	}()
+	return nil
+}
+
+// Serve uses the default registry, see func (r *FunctionTimeRegistry) Serve
+func Serve(addr string, log logging.Logger) error {
+	return defaultRegistry.Serve(addr, log)
+}
@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseReport reads a report written by the TextExporter, JSONExporter or CSVExporter, working out which from its contents
+// Text reports written before percentiles or spans were recorded are read too, with the missing values left at 0,
+// except the self time which is the same as the whole time for functions that weren't timed with spans.
+// Text reports don't keep the sums, so they are worked out from the averages
+// Counters, gauges and rates are read from every format that has them
+func ParseReport(r io.Reader) (Report, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Report{}, errors.Wrap(err, "Failed to read metrics report")
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return parseJSONReport(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("name,")):
+		return parseCSVReport(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("Registry output for ")):
+		return parseTextReport(trimmed)
+	default:
+		return Report{}, fmt.Errorf("Unrecognized metrics report format")
+	}
+}
+
+func parseJSONReport(data []byte) (Report, error) {
+	parsed := struct {
+		Time    string   `json:"time"`
+		Metrics []Metric `json:"metrics"`
+		Values  []Value  `json:"values"`
+	}{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return Report{}, errors.Wrap(err, "Failed to parse JSON metrics report")
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, parsed.Time)
+	if err != nil {
+		return Report{}, errors.Wrap(err, "Failed to parse JSON metrics report time")
+	}
+	return Report{Time: at, Metrics: parsed.Metrics, Values: parsed.Values}, nil
+}
+
+func parseCSVReport(data []byte) (Report, error) {
+	// every row has as many columns as the header, so a short or long row is an error
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return Report{}, errors.Wrap(err, "Failed to parse CSV metrics report")
+	}
+
+	// columns are found by name, so reports from before a column was added can still be read
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	column := func(row []string, name string) (int64, error) {
+		i, ok := columns[name]
+		if !ok {
+			return 0, nil
+		}
+		return strconv.ParseInt(row[i], 10, 64)
+	}
+
+	report := Report{Metrics: make([]Metric, 0, len(rows))}
+	for i, row := range rows[1:] {
+		// reports from before counters, gauges and rates were kept have no kind column, and only timings
+		kind := csvTimingKind
+		if k, ok := columns["kind"]; ok {
+			kind = row[k]
+		}
+		if kind != csvTimingKind {
+			v, err := parseCSVValue(row, columns)
+			if err != nil {
+				return Report{}, errors.Wrapf(err, "Failed to parse CSV metrics report row %d", i+1)
+			}
+			report.Values = append(report.Values, v)
+			continue
+		}
+
+		m := Metric{Name: row[columns["name"]]}
+		for name, value := range map[string]*int64{
+			"count": &m.Count, "sum_ns": &m.Sum, "self_ns": &m.Self, "avg_ns": &m.Avg, "min_ns": &m.Min, "max_ns": &m.Max,
+			"p50_ns": &m.P50, "p90_ns": &m.P90, "p99_ns": &m.P99, "p999_ns": &m.P999,
+		} {
+			if *value, err = column(row, name); err != nil {
+				return Report{}, errors.Wrapf(err, "Failed to parse CSV metrics report row %d", i+1)
+			}
+		}
+		if _, ok := columns["self_ns"]; !ok {
+			m.Self = m.Sum
+		}
+		report.Metrics = append(report.Metrics, m)
+	}
+	return report, nil
+}
+
+// parseCSVValue reads a counter, gauge or rate from a row of a CSV report
+func parseCSVValue(row []string, columns map[string]int) (Value, error) {
+	fields := make([]string, 0, 4)
+	for _, name := range []string{"name", "kind", "value", "peak"} {
+		i, ok := columns[name]
+		if !ok {
+			return Value{}, fmt.Errorf("Missing the %s column", name)
+		}
+		fields = append(fields, row[i])
+	}
+	return parseValue(fields)
+}
+
+func parseTextReport(data []byte) (Report, error) {
+	report := Report{}
+	inValues := false // whether the table of counters, gauges and rates has started
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "Metric Name") {
+			continue
+		}
+		if strings.HasPrefix(text, "Value Name") {
+			inValues = true
+			continue
+		}
+		if inValues {
+			fields := strings.Fields(text)
+			if len(fields) != 4 {
+				return Report{}, fmt.Errorf("Failed to parse text metrics report line %d: expected 4 columns, got %d", line, len(fields))
+			}
+			v, err := parseValue(fields)
+			if err != nil {
+				return Report{}, errors.Wrapf(err, "Failed to parse text metrics report line %d", line)
+			}
+			report.Values = append(report.Values, v)
+			continue
+		}
+
+		if strings.HasPrefix(text, "Registry output for ") {
+			at, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimPrefix(text, "Registry output for "), time.Local)
+			if err != nil {
+				return Report{}, errors.Wrap(err, "Failed to parse text metrics report time")
+			}
+			report.Time = at
+			continue
+		}
+
+		// the name, count, average, max and min, followed by the percentiles in newer reports,
+		// and with the self time after the average in the newest
+		fields := strings.Fields(text)
+		self := ""
+		switch len(fields) {
+		case 5, 9:
+		case 10:
+			self = fields[3]
+			fields = append(fields[:3], fields[4:]...)
+		default:
+			return Report{}, fmt.Errorf("Failed to parse text metrics report line %d: expected 5, 9 or 10 columns, got %d", line, len(fields))
+		}
+
+		// older reports wrote the average as a decimal
+		avg, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return Report{}, errors.Wrapf(err, "Failed to parse text metrics report line %d", line)
+		}
+		fields[2] = "0"
+		values, err := parseInts(fields[1:])
+		if err != nil {
+			return Report{}, errors.Wrapf(err, "Failed to parse text metrics report line %d", line)
+		}
+
+		metric := Metric{
+			Name:  fields[0],
+			Count: values[0],
+			Sum:   int64(avg * float64(values[0])),
+			Avg:   int64(avg),
+			Max:   values[2],
+			Min:   values[3],
+		}
+		metric.Self = metric.Sum
+		if self != "" {
+			avgSelf, err := strconv.ParseInt(self, 10, 64)
+			if err != nil {
+				return Report{}, errors.Wrapf(err, "Failed to parse text metrics report line %d", line)
+			}
+			metric.Self = avgSelf * metric.Count
+		}
+		if len(values) == 8 {
+			metric.P50, metric.P90, metric.P99, metric.P999 = values[4], values[5], values[6], values[7]
+		}
+		report.Metrics = append(report.Metrics, metric)
+	}
+
+	return report, errors.Wrap(scanner.Err(), "Failed to read text metrics report")
+}
+
+// parseValue reads a counter, gauge or rate from its name, kind, value and peak
+func parseValue(fields []string) (Value, error) {
+	v := Value{Name: fields[0], Kind: fields[1]}
+	if v.Kind != CounterKind && v.Kind != GaugeKind && v.Kind != RateKind {
+		return Value{}, fmt.Errorf("Unknown kind %q", v.Kind)
+	}
+
+	var err error
+	if v.Value, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return Value{}, err
+	}
+	// counters have no peak, so the CSVExporter leaves it empty
+	if fields[3] == "" {
+		return v, nil
+	}
+	if v.Peak, err = strconv.ParseFloat(fields[3], 64); err != nil {
+		return Value{}, err
+	}
+	return v, nil
+}
+
+func parseInts(fields []string) ([]int64, error) {
+	values := make([]int64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	report := Report{
+		Time: time.Now(),
+		Metrics: []Metric{
+			{Name: "Physics.Integrate", Count: 10, Sum: 1000, Self: 800, Avg: 100, Min: 50, Max: 300, P50: 90, P90: 200, P99: 300, P999: 300},
+			{Name: "Scene.Update", Count: 1, Sum: 5, Self: 5, Avg: 5, Min: 5, Max: 5, P50: 5, P90: 5, P99: 5, P999: 5},
+		},
+		Values: []Value{
+			{Name: "Owls.Alive", Kind: GaugeKind, Value: 12, Peak: 30},
+			{Name: "Owls.Killed", Kind: CounterKind, Value: 7},
+			{Name: "Owls.Spawned", Kind: RateKind, Value: 0.5, Peak: 3},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := (CSVExporter{}).Export(&buffer, report); err != nil {
+		t.Fatal(err)
+	}
+
+	// one table, with the same number of columns in every row
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 1+len(report.Metrics)+len(report.Values) {
+		t.Fatalf("expected a header and a row per metric and value, got %d lines", len(lines))
+	}
+	for i, line := range lines {
+		if columns := strings.Count(line, ",") + 1; columns != len(csvHeader) {
+			t.Errorf("line %d: expected %d columns, got %d", i+1, len(csvHeader), columns)
+		}
+	}
+	if lines[4] != "Owls.Killed,counter,,,,,,,,,,,7," {
+		t.Errorf("expected a counter to leave every other column empty, got %q", lines[4])
+	}
+
+	parsed, err := ParseReport(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Metrics, report.Metrics) {
+		t.Errorf("expected metrics %+v, got %+v", report.Metrics, parsed.Metrics)
+	}
+	if !reflect.DeepEqual(parsed.Values, report.Values) {
+		t.Errorf("expected values %+v, got %+v", report.Values, parsed.Values)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	cases := []struct {
+		name    string
+		csv     string
+		metrics []Metric
+		values  []Value
+		err     bool
+	}{
+		{
+			"without a kind column, from before values were kept",
+			"name,count,sum_ns,self_ns,avg_ns,min_ns,max_ns,p50_ns,p90_ns,p99_ns,p999_ns\nUpdate,2,10,8,5,4,6,5,6,6,6\n",
+			[]Metric{{Name: "Update", Count: 2, Sum: 10, Self: 8, Avg: 5, Min: 4, Max: 6, P50: 5, P90: 6, P99: 6, P999: 6}},
+			nil,
+			false,
+		},
+		{
+			"without self or percentiles",
+			"name,count,sum_ns,avg_ns,min_ns,max_ns\nUpdate,2,10,5,4,6\n",
+			[]Metric{{Name: "Update", Count: 2, Sum: 10, Self: 10, Avg: 5, Min: 4, Max: 6}},
+			nil,
+			false,
+		},
+		{
+			"values only",
+			"name,kind,count,sum_ns,self_ns,avg_ns,min_ns,max_ns,p50_ns,p90_ns,p99_ns,p999_ns,value,peak\nHits,counter,,,,,,,,,,,3,\n",
+			[]Metric{},
+			[]Value{{Name: "Hits", Kind: CounterKind, Value: 3}},
+			false,
+		},
+		{
+			"unknown kind",
+			"name,kind,count,sum_ns,self_ns,avg_ns,min_ns,max_ns,p50_ns,p90_ns,p99_ns,p999_ns,value,peak\nHits,meter,,,,,,,,,,,3,\n",
+			nil,
+			nil,
+			true,
+		},
+		{
+			"a row with too few columns",
+			"name,kind,count,sum_ns,self_ns,avg_ns,min_ns,max_ns,p50_ns,p90_ns,p99_ns,p999_ns,value,peak\nHits,counter,3\n",
+			nil,
+			nil,
+			true,
+		},
+		{
+			"a second table with its own header",
+			"name,count,sum_ns\nUpdate,2,10\nname,kind,value,peak\nHits,counter,3,0\n",
+			nil,
+			nil,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		report, err := ParseReport(strings.NewReader(c.csv))
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(report.Metrics, c.metrics) || !reflect.DeepEqual(report.Values, c.values) {
+			t.Errorf("%s: expected %+v and %+v, got %+v and %+v", c.name, c.metrics, c.values, report.Metrics, report.Values)
+		}
+	}
+}
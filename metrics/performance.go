@@ -2,31 +2,101 @@ package metrics
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-var defaultRegistry = FunctionTimeRegistry{
-	aggregates: make(map[string]FunctionTimeAggregate, 6),
-}
+var defaultRegistry = NewFunctionTimeRegistry()
 
 // A FunctionTimeRegistry stores aggregates of duration and call data for specific functions
 // Each unique function registered has its own aggregate
+// It also keeps named counters, gauges and rates, so the workload can be compared with the timings
 // The registry can then be used at any time (typically after some execution) to print
 // performance stats for each item in the registry
+// It is safe to time functions from any number of goroutines at once. The map of aggregates is only locked
+// for writing the first time a function is timed; after that each aggregate is updated with atomic operations
 type FunctionTimeRegistry struct {
-	aggregates map[string]FunctionTimeAggregate
+	lock       sync.RWMutex
+	aggregates map[string]*aggregate
+	counters   map[string]*int64
+	gauges     map[string]*gauge
+	rates      map[string]*meter
+	recorder   atomic.Value // a recorderBox, holding where individual events are kept, if anywhere
+	monitor    atomic.Value // a monitorBox, holding the frame monitor that timed functions are attributed to, if any
+	tracers    int64        // the number of tracers created, to give each its own thread in recorded events
+	generation int64        // the number of times the registry has been reset, only accessed atomically
 }
 
 // A FunctionTimeAggregate represents the collected data for a function
 // It is designed to be quick; expensive statistics will be calculated afterwards from it
 type FunctionTimeAggregate struct {
-	Sum   int64
-	Max   int64
-	Min   int64
-	Count int64
+	Sum       int64
+	Self      int64 // the part of Sum not spent in nested spans. For functions timed with Timed, it's the same as Sum
+	Max       int64
+	Min       int64
+	Count     int64
+	Histogram Histogram // the distribution of durations, for percentiles
+}
+
+// Percentile returns a duration that at least p percent of the calls took no longer than, for p between 0 and 100
+// It is never more than the longest call
+func (a *FunctionTimeAggregate) Percentile(p float64) int64 {
+	percentile := a.Histogram.Percentile(p)
+	if percentile > a.Max {
+		return a.Max
+	}
+	return percentile
+}
+
+// Since returns what was recorded after the earlier snapshot of the same aggregate was taken
+// The min and max of each window aren't kept, so they are worked out from the histogram, to within 1/8th
+// Both must be from the same generation of the registry; after a reset, the earlier snapshot means nothing
+func (a FunctionTimeAggregate) Since(earlier FunctionTimeAggregate) FunctionTimeAggregate {
+	window := FunctionTimeAggregate{
+		Sum:       a.Sum - earlier.Sum,
+		Self:      a.Self - earlier.Self,
+		Count:     a.Count - earlier.Count,
+		Histogram: a.Histogram.since(&earlier.Histogram),
+	}
+	if window.Count == 0 {
+		return window
+	}
+
+	// the estimates can't be beyond the whole run's, which are exact
+	window.Min = window.Histogram.Min()
+	if window.Min < a.Min {
+		window.Min = a.Min
+	}
+	window.Max = window.Histogram.Percentile(100)
+	if window.Max > a.Max {
+		window.Max = a.Max
+	}
+	return window
+}
+
+// aggregate is the live version of a FunctionTimeAggregate, whose fields are only accessed atomically
+type aggregate struct {
+	sum       int64
+	self      int64
+	max       int64
+	min       int64
+	count     int64
+	frameSelf int64 // self time since the attached frame monitor's frame began, see FrameMonitor
+	histogram Histogram
+}
+
+// NewFunctionTimeRegistry constructs an empty registry
+func NewFunctionTimeRegistry() *FunctionTimeRegistry {
+	return &FunctionTimeRegistry{
+		aggregates: make(map[string]*aggregate, 6),
+		counters:   make(map[string]*int64, 6),
+		gauges:     make(map[string]*gauge, 6),
+		rates:      make(map[string]*meter, 6),
+	}
 }
 
 // Func returns a tuple that represents a timeable event
@@ -46,20 +116,10 @@ func Func(name string) (string, time.Time) {
 // defer r.Timed(r.Func("functionUnderTest"))
 func (r *FunctionTimeRegistry) Timed(name string, start time.Time) {
 	delta := time.Since(start).Nanoseconds()
-	aggregate, ok := r.aggregates[name]
-	if !ok {
-		aggregate = FunctionTimeAggregate{Sum: 0, Max: delta, Min: delta, Count: 0}
-	}
-	aggregate.Count++
-	aggregate.Sum += delta
-	if delta > aggregate.Max {
-		aggregate.Max = delta
-	}
-	if delta < aggregate.Min {
-		aggregate.Min = delta
+	r.record(name, delta, delta)
+	if recorder := r.EventRecorder(); recorder != nil {
+		recorder.Record(Event{Name: name, Path: name, Start: start, Duration: delta})
 	}
-
-	r.aggregates[name] = aggregate
 }
 
 // Timed uses the default registry, see func (r *FunctionTimeRegistry) Timed
@@ -67,46 +127,144 @@ func Timed(name string, start time.Time) {
 	defaultRegistry.Timed(name, start)
 }
 
-type metricsRow struct {
-	name                 string
-	count, max, min, avg int64
+// record adds a single call that took delta nanoseconds, self of which weren't spent in nested spans
+func (r *FunctionTimeRegistry) record(name string, delta, self int64) {
+	a := r.aggregate(name)
+
+	atomic.AddInt64(&a.count, 1)
+	atomic.AddInt64(&a.sum, delta)
+	atomic.AddInt64(&a.self, self)
+	if r.frameMonitor() != nil {
+		atomic.AddInt64(&a.frameSelf, self)
+	}
+	a.histogram.Record(delta)
+	for max := atomic.LoadInt64(&a.max); delta > max; max = atomic.LoadInt64(&a.max) {
+		if atomic.CompareAndSwapInt64(&a.max, max, delta) {
+			break
+		}
+	}
+	for min := atomic.LoadInt64(&a.min); delta < min; min = atomic.LoadInt64(&a.min) {
+		if atomic.CompareAndSwapInt64(&a.min, min, delta) {
+			break
+		}
+	}
 }
 
-// Output prints the statistics for all aggregated events in this registry to the given file
-// It overrites the given file if present
-func (r *FunctionTimeRegistry) Output(filepath string) error {
-	if len(r.aggregates) == 0 {
-		return fmt.Errorf("No metrics were collected")
+// aggregate returns the live aggregate for the given name, creating it if this is the first time it's been timed
+func (r *FunctionTimeRegistry) aggregate(name string) *aggregate {
+	r.lock.RLock()
+	a, ok := r.aggregates[name]
+	r.lock.RUnlock()
+	if ok {
+		return a
 	}
 
-	file, err := os.Create(filepath)
-	if err != nil {
-		return errors.Wrap(err, "Failed to open registry output file")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// another goroutine may have created it while the lock was released
+	if a, ok := r.aggregates[name]; ok {
+		return a
 	}
+	a = &aggregate{min: int64(^uint64(0) >> 1)}
+	r.aggregates[name] = a
+	return a
+}
+
+// Reset forgets every aggregate, counter, gauge and rate in the registry, and the events in its recorder,
+// so everything is collected from scratch
+// A function being timed while the registry is reset may be recorded in either the old or the new aggregates
+func (r *FunctionTimeRegistry) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
-	fmt.Fprintf(file, "Registry output for %s\n", time.Now().Local().Format("2006-01-02 15:04:05"))
-	rows := make([]metricsRow, 0, len(r.aggregates))
-	for name, aggregate := range r.aggregates {
-		rows = append(rows, metricsRow{
-			name:  name,
-			count: aggregate.Count,
-			avg:   int64(float64(aggregate.Sum) / float64(aggregate.Count)),
-			max:   aggregate.Max,
-			min:   aggregate.Min,
-		})
+	if recorder := r.EventRecorder(); recorder != nil {
+		recorder.Clear()
 	}
 
-	for i, r := range rows {
-		if i%10 == 0 {
-			fmt.Fprintf(file, "\n%30s   %10s   %10s   %10s   %10s\n\n", "Metric Name", "Count(##)", "Avg(ns)", "Max(ns)", "Min(ns)")
+	atomic.AddInt64(&r.generation, 1)
+	r.aggregates = make(map[string]*aggregate, len(r.aggregates))
+	r.counters = make(map[string]*int64, len(r.counters))
+	r.gauges = make(map[string]*gauge, len(r.gauges))
+	r.rates = make(map[string]*meter, len(r.rates))
+}
+
+// Reset uses the default registry, see func (r *FunctionTimeRegistry) Reset
+func Reset() {
+	defaultRegistry.Reset()
+}
+
+// Generation returns the number of times the registry has been reset, so snapshots from before a reset can be told apart
+func (r *FunctionTimeRegistry) Generation() int64 {
+	return atomic.LoadInt64(&r.generation)
+}
+
+// Snapshot returns a copy of every aggregate in the registry, by name
+// Each aggregate is read field by field, so one that is being timed while the snapshot is taken may be off by that call
+func (r *FunctionTimeRegistry) Snapshot() map[string]FunctionTimeAggregate {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	snapshot := make(map[string]FunctionTimeAggregate, len(r.aggregates))
+	for name, a := range r.aggregates {
+		snapshot[name] = FunctionTimeAggregate{
+			Sum:       atomic.LoadInt64(&a.sum),
+			Self:      atomic.LoadInt64(&a.self),
+			Max:       atomic.LoadInt64(&a.max),
+			Min:       atomic.LoadInt64(&a.min),
+			Count:     atomic.LoadInt64(&a.count),
+			Histogram: a.histogram.snapshot(),
 		}
-		fmt.Fprintf(file, "%30s   %10d   %10d   %10d   %10d\n", r.name, r.count, r.avg, r.max, r.min)
+	}
+	return snapshot
+}
+
+// Snapshot uses the default registry, see func (r *FunctionTimeRegistry) Snapshot
+func Snapshot() map[string]FunctionTimeAggregate {
+	return defaultRegistry.Snapshot()
+}
+
+// CurrentReport summarizes every metric in the registry as it is right now
+func (r *FunctionTimeRegistry) CurrentReport() Report {
+	now := time.Now()
+	report := NewReport(now, r.Snapshot())
+	report.Values = r.Values(now)
+	return report
+}
+
+// CurrentReport uses the default registry, see func (r *FunctionTimeRegistry) CurrentReport
+func CurrentReport() Report {
+	return defaultRegistry.CurrentReport()
+}
+
+// Write exports the statistics for all aggregated events in this registry to the given writer
+func (r *FunctionTimeRegistry) Write(w io.Writer, exporter Exporter) error {
+	report := r.CurrentReport()
+	if len(report.Metrics) == 0 && len(report.Values) == 0 {
+		return fmt.Errorf("No metrics were collected")
+	}
+
+	return errors.Wrap(exporter.Export(w, report), "Failed to export metrics")
+}
+
+// Write uses the default registry, see func (r *FunctionTimeRegistry) Write
+func Write(w io.Writer, exporter Exporter) error {
+	return defaultRegistry.Write(w, exporter)
+}
+
+// Output exports the statistics for all aggregated events in this registry to the given file
+// It overrites the given file if present, atomically, so the file is never left half written
+func (r *FunctionTimeRegistry) Output(filepath string, exporter Exporter) error {
+	report := r.CurrentReport()
+	if len(report.Metrics) == 0 && len(report.Values) == 0 {
+		return fmt.Errorf("No metrics were collected")
 	}
 
-	return nil
+	err := writeAtomically(filepath, func(w io.Writer) error { return exporter.Export(w, report) })
+	return errors.Wrap(err, "Failed to write registry output file")
 }
 
 // Output uses the default registry, see func (r *FunctionTimeRegistry) Output
-func Output(filepath string) error {
-	return defaultRegistry.Output(filepath)
+func Output(filepath string, exporter Exporter) error {
+	return defaultRegistry.Output(filepath, exporter)
 }
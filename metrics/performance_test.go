@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bcokert/engo-test/logging"
+)
+
+// Run with -race to check that timing, snapshotting and resetting are safe to do from many goroutines at once
+func TestTimedFromManyGoroutines(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+
+	const goroutines = 8
+	const calls = 1000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				r.Timed(r.Func(fmt.Sprintf("function%d", i%4)))
+				r.Timed(r.Func(fmt.Sprintf("goroutine%d", g)))
+			}
+		}(g)
+	}
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				r.Snapshot()
+				r.CurrentReport()
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	snapshot := r.Snapshot()
+	total := int64(0)
+	for i := 0; i < 4; i++ {
+		total += snapshot[fmt.Sprintf("function%d", i)].Count
+	}
+	if total != goroutines*calls {
+		t.Errorf("expected %d calls to be counted, got %d", goroutines*calls, total)
+	}
+	for g := 0; g < goroutines; g++ {
+		aggregate := snapshot[fmt.Sprintf("goroutine%d", g)]
+		if aggregate.Count != calls {
+			t.Errorf("goroutine%d: expected %d calls, got %d", g, calls, aggregate.Count)
+		}
+		if aggregate.Histogram.Count() != calls {
+			t.Errorf("goroutine%d: expected %d calls in the histogram, got %d", g, calls, aggregate.Histogram.Count())
+		}
+		if aggregate.Min > aggregate.Max {
+			t.Errorf("goroutine%d: min %d is more than max %d", g, aggregate.Min, aggregate.Max)
+		}
+	}
+}
+
+func TestResetWhileTiming(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				r.Timed(r.Func("function"))
+				r.Count("counter", 1)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		r.Reset()
+		r.Snapshot()
+	}
+	wg.Wait()
+
+	r.Reset()
+	if report := r.CurrentReport(); len(report.Metrics) != 0 || len(report.Values) != 0 {
+		t.Errorf("expected nothing after a reset, got %d metrics and %d values", len(report.Metrics), len(report.Values))
+	}
+}
+
+func TestTimedRecordsDuration(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	r.Timed("function", time.Now().Add(-time.Millisecond))
+
+	aggregate := r.Snapshot()["function"]
+	if aggregate.Count != 1 || aggregate.Sum < int64(time.Millisecond) || aggregate.Self != aggregate.Sum {
+		t.Errorf("expected a single call of at least 1ms, got %+v", aggregate)
+	}
+}
+
+func BenchmarkTimed(b *testing.B) {
+	r := NewFunctionTimeRegistry()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Timed(r.Func("function"))
+		}
+	})
+}
+
+func TestResetClearsRecorder(t *testing.T) {
+	r := NewFunctionTimeRegistry()
+	recorder := NewRecorder(2)
+	r.SetRecorder(recorder)
+	for i := 0; i < 3; i++ {
+		r.Timed("before", time.Now())
+	}
+
+	r.Reset()
+	if len(recorder.Events()) != 0 || recorder.Dropped() != 0 {
+		t.Errorf("expected the recorder to be empty after a reset, got %d events and %d dropped", len(recorder.Events()), recorder.Dropped())
+	}
+
+	r.Timed("after", time.Now())
+	if events := recorder.Events(); len(events) != 1 || events[0].Name != "after" {
+		t.Errorf("expected only the event after the reset, got %+v", events)
+	}
+}
+
+// Attaching a frame monitor must not put a shared lock back in the way of every timed call
+func BenchmarkTimedWithFrameMonitor(b *testing.B) {
+	r := NewFunctionTimeRegistry()
+	monitor := NewFrameMonitor(DefaultFrameBudget(), logging.NewDefaultLogger(logging.INFO))
+	r.SetFrameMonitor(monitor)
+	monitor.BeginFrame()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Timed(r.Func("function"))
+		}
+	})
+	monitor.EndFrame()
+}
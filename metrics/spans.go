@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PathSeparator separates the names of nested spans in a call path, like "Engine.Total/Engine.Integrate"
+const PathSeparator = "/"
+
+// A Span identifies an open span, and is returned by Start to be passed to End
+type Span int
+
+type frame struct {
+	name     string
+	path     string
+	start    time.Time
+	children int64 // nanoseconds spent in spans started inside this one
+}
+
+type pathKey struct {
+	parent string
+	name   string
+}
+
+// A Tracer times nested spans, and records each one under its call path with both its inclusive time
+// and its exclusive (self) time, which doesn't count the time spent in the spans started inside it
+// A tracer keeps the stack of open spans for a single goroutine, so every goroutine that times spans needs its own
+// It's meant to be used like Timed:
+// defer t.End(t.Start("functionUnderTest"))
+type Tracer struct {
+	registry *FunctionTimeRegistry
+	id       int64 // identifies the tracer's events when they are recorded
+	frames   []frame
+	paths    map[pathKey]string // the call path of each name under each parent, so paths are only built once
+}
+
+// NewTracer constructs a tracer that records its spans in this registry
+func (r *FunctionTimeRegistry) NewTracer() *Tracer {
+	return &Tracer{
+		registry: r,
+		id:       atomic.AddInt64(&r.tracers, 1),
+		frames:   make([]frame, 0, 16),
+		paths:    make(map[pathKey]string, 16),
+	}
+}
+
+// Start opens a span inside the innermost open span
+func (t *Tracer) Start(name string) Span {
+	parent := ""
+	if len(t.frames) > 0 {
+		parent = t.frames[len(t.frames)-1].path
+	}
+
+	key := pathKey{parent: parent, name: name}
+	path, ok := t.paths[key]
+	if !ok {
+		path = name
+		if parent != "" {
+			path = parent + PathSeparator + name
+		}
+		t.paths[key] = path
+	}
+
+	t.frames = append(t.frames, frame{name: name, path: path, start: time.Now()})
+	return Span(len(t.frames) - 1)
+}
+
+// End closes a span and records it. Any spans opened inside it that are still open are closed first
+func (t *Tracer) End(span Span) {
+	now := time.Now()
+	recorder := t.registry.EventRecorder()
+	for len(t.frames) > int(span) {
+		f := t.frames[len(t.frames)-1]
+		t.frames = t.frames[:len(t.frames)-1]
+
+		delta := now.Sub(f.start).Nanoseconds()
+		if len(t.frames) > 0 {
+			t.frames[len(t.frames)-1].children += delta
+		}
+		t.registry.record(f.path, delta, delta-f.children)
+		if recorder != nil {
+			recorder.Record(Event{Name: f.name, Path: f.path, Start: f.start, Duration: delta, Thread: t.id})
+		}
+	}
+}
+
+// defaultTracer belongs to the game loop's goroutine
+var defaultTracer = defaultRegistry.NewTracer()
+
+// Start uses the default tracer, see func (t *Tracer) Start
+// The default tracer must only be used from the game loop; other goroutines should use Timed, or their own tracer
+func Start(name string) Span {
+	return defaultTracer.Start(name)
+}
+
+// End uses the default tracer, see func (t *Tracer) End
+func End(span Span) {
+	defaultTracer.End(span)
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// An Event is a single timed call, as kept by a Recorder
+type Event struct {
+	Name     string    // the function name, or for spans the name of the innermost span
+	Path     string    // the call path of a span, or the function name for Timed
+	Start    time.Time // when the call started
+	Duration int64     // nanoseconds
+	Thread   int64     // the tracer that timed the call, or 0 for Timed
+}
+
+// A Recorder keeps the most recent timed events in a ring buffer of fixed size, so that a long run can be
+// recorded without running out of memory; once it's full, each new event replaces the oldest one
+type Recorder struct {
+	lock    sync.Mutex
+	events  []Event
+	next    int  // where the next event is written
+	wrapped bool // whether the buffer has filled up and started replacing events
+	dropped int64
+}
+
+// NewRecorder constructs a recorder that keeps at most capacity events
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Recorder{events: make([]Event, capacity)}
+}
+
+// Record keeps an event, replacing the oldest one if the recorder is full
+func (r *Recorder) Record(event Event) {
+	r.lock.Lock()
+	if r.wrapped {
+		r.dropped++
+	}
+	r.events[r.next] = event
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.wrapped = true
+	}
+	r.lock.Unlock()
+}
+
+// Events returns a copy of the kept events, oldest first
+func (r *Recorder) Events() []Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.wrapped {
+		return append([]Event(nil), r.events[:r.next]...)
+	}
+	events := make([]Event, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// Dropped returns the number of events that have been replaced by newer ones
+func (r *Recorder) Dropped() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.dropped
+}
+
+// Clear forgets every kept event, and the count of dropped ones, so the recorder starts over empty
+func (r *Recorder) Clear() {
+	r.lock.Lock()
+	r.next = 0
+	r.wrapped = false
+	r.dropped = 0
+	r.lock.Unlock()
+}
+
+// chromeEvent is a complete event in the Chrome Trace Event format, with times in microseconds
+type chromeEvent struct {
+	Name     string            `json:"name"`
+	Phase    string            `json:"ph"`
+	Time     float64           `json:"ts"`
+	Duration float64           `json:"dur"`
+	Process  int               `json:"pid"`
+	Thread   int64             `json:"tid"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the kept events in the Chrome Trace Event format, which can be opened in
+// chrome://tracing or Perfetto to see every call on a timeline. Times are relative to the earliest start
+func (r *Recorder) WriteChromeTrace(w io.Writer) error {
+	events := r.Events()
+	trace := struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{
+		TraceEvents:     make([]chromeEvent, 0, len(events)),
+		DisplayTimeUnit: "ms",
+	}
+
+	// spans are kept when they end, so a parent is kept after its children but started before them
+	var origin time.Time
+	for i, event := range events {
+		if i == 0 || event.Start.Before(origin) {
+			origin = event.Start
+		}
+	}
+	for _, event := range events {
+		var args map[string]string
+		if event.Path != event.Name {
+			args = map[string]string{"path": event.Path}
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeEvent{
+			Name:     event.Name,
+			Phase:    "X",
+			Time:     float64(event.Start.Sub(origin).Nanoseconds()) / 1000,
+			Duration: float64(event.Duration) / 1000,
+			Process:  1,
+			Thread:   event.Thread,
+			Args:     args,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(trace)
+}
+
+// SetRecorder starts keeping every event timed in this registry in the given recorder, or stops if it is nil
+func (r *FunctionTimeRegistry) SetRecorder(recorder *Recorder) {
+	r.recorder.Store(recorderBox{recorder})
+}
+
+// SetRecorder uses the default registry, see func (r *FunctionTimeRegistry) SetRecorder
+func SetRecorder(recorder *Recorder) {
+	defaultRegistry.SetRecorder(recorder)
+}
+
+// EventRecorder returns the recorder that events timed in this registry are kept in, or nil if they aren't being kept
+func (r *FunctionTimeRegistry) EventRecorder() *Recorder {
+	box, _ := r.recorder.Load().(recorderBox)
+	return box.recorder
+}
+
+// EventRecorder uses the default registry, see func (r *FunctionTimeRegistry) EventRecorder
+func EventRecorder() *Recorder {
+	return defaultRegistry.EventRecorder()
+}
+
+// recorderBox lets a nil recorder be stored in an atomic.Value, which can't store nil itself
+type recorderBox struct {
+	recorder *Recorder
+}
+
+// OutputChromeTrace writes the kept events to the given file in the Chrome Trace Event format
+// It overwrites the given file if present, atomically, so the file is never left half written
+func (r *Recorder) OutputChromeTrace(filepath string) error {
+	return errors.Wrap(writeAtomically(filepath, r.WriteChromeTrace), "Failed to write trace output file")
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The kinds of Value
+const (
+	CounterKind = "counter"
+	GaugeKind   = "gauge"
+	RateKind    = "rate"
+)
+
+// A Value is the summary of a single counter, gauge or rate
+type Value struct {
+	Name  string  `json:"name"`
+	Kind  string  `json:"kind"`           // CounterKind, GaugeKind or RateKind
+	Value float64 `json:"value"`          // a counter's total, a gauge's latest value, or a rate's average per second
+	Peak  float64 `json:"peak,omitempty"` // a gauge's highest value, or a rate's busiest second. Counters have none
+}
+
+// gauge is a value that is set rather than added to, whose fields are float64 bits only accessed atomically
+type gauge struct {
+	value uint64
+	peak  uint64
+}
+
+// meter counts events to measure how often they happen, both on average and in the busiest second
+type meter struct {
+	lock   sync.Mutex
+	first  time.Time // when the first event happened; the average is over the time since then
+	total  int64
+	second time.Time // the start of the second currently being counted
+	count  int64     // events in the current second
+	peak   int64     // events in the busiest finished second
+}
+
+// Count adds n to the named counter, like the number of collisions detected
+func (r *FunctionTimeRegistry) Count(name string, n int64) {
+	r.lock.RLock()
+	counter, ok := r.counters[name]
+	r.lock.RUnlock()
+	if !ok {
+		r.lock.Lock()
+		if counter, ok = r.counters[name]; !ok {
+			counter = new(int64)
+			r.counters[name] = counter
+		}
+		r.lock.Unlock()
+	}
+
+	atomic.AddInt64(counter, n)
+}
+
+// Count uses the default registry, see func (r *FunctionTimeRegistry) Count
+func Count(name string, n int64) {
+	defaultRegistry.Count(name, n)
+}
+
+// Gauge sets the named gauge to its latest value, like the number of live particles
+func (r *FunctionTimeRegistry) Gauge(name string, value float64) {
+	r.lock.RLock()
+	g, ok := r.gauges[name]
+	r.lock.RUnlock()
+	if !ok {
+		r.lock.Lock()
+		if g, ok = r.gauges[name]; !ok {
+			g = &gauge{value: math.Float64bits(value), peak: math.Float64bits(value)}
+			r.gauges[name] = g
+		}
+		r.lock.Unlock()
+	}
+
+	atomic.StoreUint64(&g.value, math.Float64bits(value))
+	for peak := atomic.LoadUint64(&g.peak); value > math.Float64frombits(peak); peak = atomic.LoadUint64(&g.peak) {
+		if atomic.CompareAndSwapUint64(&g.peak, peak, math.Float64bits(value)) {
+			break
+		}
+	}
+}
+
+// Gauge uses the default registry, see func (r *FunctionTimeRegistry) Gauge
+func Gauge(name string, value float64) {
+	defaultRegistry.Gauge(name, value)
+}
+
+// Mark counts n events for the named rate, like the number of owls spawned
+func (r *FunctionTimeRegistry) Mark(name string, n int64) {
+	r.lock.RLock()
+	m, ok := r.rates[name]
+	r.lock.RUnlock()
+	if !ok {
+		r.lock.Lock()
+		if m, ok = r.rates[name]; !ok {
+			m = &meter{}
+			r.rates[name] = m
+		}
+		r.lock.Unlock()
+	}
+
+	now := time.Now()
+	m.lock.Lock()
+	if m.first.IsZero() {
+		m.first = now
+		m.second = now
+	}
+	m.roll(now)
+	m.total += n
+	m.count += n
+	m.lock.Unlock()
+}
+
+// Mark uses the default registry, see func (r *FunctionTimeRegistry) Mark
+func Mark(name string, n int64) {
+	defaultRegistry.Mark(name, n)
+}
+
+// roll finishes the current second if it's over, keeping it as the peak if it was the busiest. The lock must be held
+func (m *meter) roll(now time.Time) {
+	if now.Sub(m.second) < time.Second {
+		return
+	}
+	if m.count > m.peak {
+		m.peak = m.count
+	}
+	m.count = 0
+	m.second = now
+}
+
+// value summarizes the meter as of the given time
+func (m *meter) value(name string, now time.Time) Value {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.roll(now)
+	peak := m.peak
+	if m.count > peak {
+		peak = m.count
+	}
+
+	// an average over less than a second would overstate a burst of events, so it's over at least one
+	elapsed := now.Sub(m.first).Seconds()
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	return Value{Name: name, Kind: RateKind, Value: float64(m.total) / elapsed, Peak: float64(peak)}
+}
+
+// Values returns the summary of every counter, gauge and rate in the registry as of the given time, sorted by name
+func (r *FunctionTimeRegistry) Values(now time.Time) []Value {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	values := make([]Value, 0, len(r.counters)+len(r.gauges)+len(r.rates))
+	for name, counter := range r.counters {
+		values = append(values, Value{Name: name, Kind: CounterKind, Value: float64(atomic.LoadInt64(counter))})
+	}
+	for name, g := range r.gauges {
+		values = append(values, Value{
+			Name:  name,
+			Kind:  GaugeKind,
+			Value: math.Float64frombits(atomic.LoadUint64(&g.value)),
+			Peak:  math.Float64frombits(atomic.LoadUint64(&g.peak)),
+		})
+	}
+	for name, m := range r.rates {
+		values = append(values, m.value(name, now))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values
+}
+
+// Values uses the default registry, see func (r *FunctionTimeRegistry) Values
+func Values(now time.Time) []Value {
+	return defaultRegistry.Values(now)
+}
+
+// totals returns how many times each counter and rate has been added to or marked, by name
+func (r *FunctionTimeRegistry) totals() map[string]int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	totals := make(map[string]int64, len(r.counters)+len(r.rates))
+	for name, counter := range r.counters {
+		totals[name] = atomic.LoadInt64(counter)
+	}
+	for name, m := range r.rates {
+		m.lock.Lock()
+		totals[name] = m.total
+		m.lock.Unlock()
+	}
+	return totals
+}
+
+// windowValues returns the counters and rates as they've changed since the earlier totals were taken,
+// the given number of seconds ago, and the gauges as they are now. Both totals must be from the same generation
+// Peaks are only kept for the whole run, so the values of a window have none
+func windowValues(values []Value, totals, earlier map[string]int64, seconds float64) []Value {
+	window := make([]Value, 0, len(values))
+	for _, v := range values {
+		change := float64(totals[v.Name] - earlier[v.Name])
+		switch v.Kind {
+		case CounterKind:
+			window = append(window, Value{Name: v.Name, Kind: v.Kind, Value: change})
+		case GaugeKind:
+			window = append(window, Value{Name: v.Name, Kind: v.Kind, Value: v.Value})
+		case RateKind:
+			if seconds > 0 {
+				change /= seconds
+			}
+			window = append(window, Value{Name: v.Name, Kind: v.Kind, Value: change})
+		}
+	}
+	return window
+}
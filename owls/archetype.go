@@ -0,0 +1,211 @@
+package owls
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/engoengine/math"
+
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/flocking"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/pkg/errors"
+)
+
+// An Archetype describes a kind of owl. Every owl spawned from an archetype rolls its
+// scale, health and speed from the archetype's ranges
+type Archetype struct {
+	Name            string            `json:"name"`
+	Texture         string            `json:"texture"` // the url of the texture, relative to the assets folder
+	MinScale        float32           `json:"minScale"`
+	MaxScale        float32           `json:"maxScale"`
+	Mass            float32           `json:"mass"`
+	Restitution     float32           `json:"restitution"`     // the percentage of velocity retained after a collision
+	Armor           float32           `json:"armor"`           // the fraction of impact damage that is ignored, between 0 and 1
+	Regeneration    float32           `json:"regeneration"`    // health regained per second
+	Invulnerability float32           `json:"invulnerability"` // seconds after being damaged during which no more damage is taken
+	MinHealth       int               `json:"minHealth"`
+	MaxHealth       int               `json:"maxHealth"`
+	MinSpeed        float32           `json:"minSpeed"` // pixels per second
+	MaxSpeed        float32           `json:"maxSpeed"` // pixels per second
+	Weight          float32           `json:"weight"`   // how likely this archetype is to be picked, relative to the others
+	Flocking        *flocking.Weights `json:"flocking"` // how owls of this archetype flock together, with defaults for any weights left out. If nil, they don't flock
+}
+
+// RollScale returns a random scale within the archetype's range
+func (a *Archetype) RollScale(r *rand.Rand) float32 {
+	return a.MinScale + r.Float32()*(a.MaxScale-a.MinScale)
+}
+
+// RollHealth returns a random whole health within the archetype's range
+func (a *Archetype) RollHealth(r *rand.Rand) float32 {
+	return float32(a.MinHealth + r.Intn(a.MaxHealth-a.MinHealth+1))
+}
+
+// RollVelocity returns a velocity in a random direction, with a speed within the archetype's range
+func (a *Archetype) RollVelocity(r *rand.Rand) engo.Point {
+	speed := a.MinSpeed + r.Float32()*(a.MaxSpeed-a.MinSpeed)
+	angle := r.Float32() * 2 * math.Pi
+	return engo.Point{math.Cos(angle) * speed, math.Sin(angle) * speed}
+}
+
+// An ArchetypeTable is a set of archetypes that can be picked from by weight
+type ArchetypeTable struct {
+	Archetypes  []Archetype
+	totalWeight float32
+}
+
+// NewArchetypeTable constructs a table from the given archetypes, which must be valid
+func NewArchetypeTable(archetypes []Archetype) *ArchetypeTable {
+	table := &ArchetypeTable{Archetypes: archetypes}
+	for _, archetype := range archetypes {
+		table.totalWeight += archetype.Weight
+	}
+	return table
+}
+
+// DefaultArchetypeTable returns a table with the single kind of owl used when no archetype file is given
+func DefaultArchetypeTable() *ArchetypeTable {
+	return NewArchetypeTable([]Archetype{
+		{
+			Name:        "owl",
+			Texture:     "textures/owl.png",
+			MinScale:    0.25,
+			MaxScale:    0.75,
+			Mass:        1,
+			Restitution: 0.7,
+			MinHealth:   2,
+			MaxHealth:   6,
+			MinSpeed:    0,
+			MaxSpeed:    200,
+			Weight:      1,
+		},
+	})
+}
+
+// LoadArchetypeTable reads and validates the archetype file at the given path
+// Every validation problem is logged, and an error is returned if there were any
+func LoadArchetypeTable(path string, log logging.Logger) (*ArchetypeTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open archetype file")
+	}
+	defer file.Close()
+
+	archetypes := []Archetype{}
+	if err := json.NewDecoder(file).Decode(&archetypes); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse archetype file")
+	}
+
+	problems := validateArchetypes(archetypes)
+	for _, problem := range problems {
+		log.Error("Invalid archetype file", logging.F{"path": path, "problem": problem})
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("Archetype file %s has %d problems", path, len(problems))
+	}
+
+	return NewArchetypeTable(archetypes), nil
+}
+
+func validateArchetypes(archetypes []Archetype) []string {
+	problems := []string{}
+	if len(archetypes) == 0 {
+		problems = append(problems, "there must be at least 1 archetype")
+	}
+
+	names := make(map[string]bool, len(archetypes))
+	for i, a := range archetypes {
+		if a.Name == "" {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] has no name", i))
+		}
+		if names[a.Name] {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] reuses the name %q", i, a.Name))
+		}
+		names[a.Name] = true
+		if a.Texture == "" {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] has no texture", i))
+		}
+		if a.MinScale <= 0 || a.MaxScale < a.MinScale {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] scale range [%v, %v] must be positive and ordered", i, a.MinScale, a.MaxScale))
+		}
+		if a.Mass < 0 {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] mass must not be negative, got %v", i, a.Mass))
+		}
+		if a.Restitution < 0 || a.Restitution > 1 {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] restitution must be between 0 and 1, got %v", i, a.Restitution))
+		}
+		if a.Armor < 0 || a.Armor > 1 {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] armor must be between 0 and 1, got %v", i, a.Armor))
+		}
+		if a.Regeneration < 0 || a.Invulnerability < 0 {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] regeneration %v and invulnerability %v must not be negative", i, a.Regeneration, a.Invulnerability))
+		}
+		if a.MinHealth <= 0 || a.MaxHealth < a.MinHealth {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] health range [%d, %d] must be positive and ordered", i, a.MinHealth, a.MaxHealth))
+		}
+		if a.MinSpeed < 0 || a.MaxSpeed < a.MinSpeed {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] speed range [%v, %v] must not be negative and must be ordered", i, a.MinSpeed, a.MaxSpeed))
+		}
+		if a.Weight <= 0 {
+			problems = append(problems, fmt.Sprintf("archetypes[%d] weight must be positive, got %v", i, a.Weight))
+		}
+		if a.Flocking != nil {
+			for _, problem := range a.Flocking.Validate() {
+				problems = append(problems, fmt.Sprintf("archetypes[%d] %s", i, problem))
+			}
+		}
+	}
+
+	return problems
+}
+
+// Pick returns a random archetype, where each archetype is picked in proportion to its weight
+func (t *ArchetypeTable) Pick(r *rand.Rand) *Archetype {
+	target := r.Float32() * t.totalWeight
+	for i := range t.Archetypes {
+		target -= t.Archetypes[i].Weight
+		if target < 0 {
+			return &t.Archetypes[i]
+		}
+	}
+
+	// rounding can leave a tiny bit of weight over, which belongs to the last archetype
+	return &t.Archetypes[len(t.Archetypes)-1]
+}
+
+// Get returns the archetype with the given name, or nil if there is none
+func (t *ArchetypeTable) Get(name string) *Archetype {
+	for i := range t.Archetypes {
+		if t.Archetypes[i].Name == name {
+			return &t.Archetypes[i]
+		}
+	}
+	return nil
+}
+
+// Subset returns a table with only the named archetypes. Names that aren't in this table are ignored
+func (t *ArchetypeTable) Subset(names []string) *ArchetypeTable {
+	archetypes := make([]Archetype, 0, len(names))
+	for _, name := range names {
+		if archetype := t.Get(name); archetype != nil {
+			archetypes = append(archetypes, *archetype)
+		}
+	}
+	return NewArchetypeTable(archetypes)
+}
+
+// Textures returns the unique texture urls used by the archetypes in this table
+func (t *ArchetypeTable) Textures() []string {
+	seen := make(map[string]bool, len(t.Archetypes))
+	textures := make([]string, 0, len(t.Archetypes))
+	for _, archetype := range t.Archetypes {
+		if !seen[archetype.Texture] {
+			seen[archetype.Texture] = true
+			textures = append(textures, archetype.Texture)
+		}
+	}
+	return textures
+}
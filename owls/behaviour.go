@@ -0,0 +1,157 @@
+package owls
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/fsm"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/steering"
+)
+
+// The states an owl can be in
+const (
+	Idle    = "idle"    // hovering in place for a rest
+	Flying  = "flying"  // wandering about
+	Fleeing = "fleeing" // wounded, and flying away from the cursor
+	Stunned = "stunned" // knocked senseless by a hard impact, and falling
+	Dying   = "dying"   // out of health, and fading away before it is removed
+)
+
+// BehaviourRules configure how long owls spend in each of their states
+type BehaviourRules struct {
+	MinFlyTime  float32 `json:"minFlyTime"`  // the least time, in seconds, an owl flies before stopping for a rest
+	MaxFlyTime  float32 `json:"maxFlyTime"`  // the most time, in seconds, an owl flies before stopping for a rest
+	MinIdleTime float32 `json:"minIdleTime"` // the least time, in seconds, an owl rests before flying again
+	MaxIdleTime float32 `json:"maxIdleTime"` // the most time, in seconds, an owl rests before flying again
+	StunTime    float32 `json:"stunTime"`    // seconds an owl is stunned for after a damaging impact
+	DyingTime   float32 `json:"dyingTime"`   // seconds an owl takes to fade away once it dies
+}
+
+// DefaultBehaviourRules returns the rules used when none are configured
+func DefaultBehaviourRules() BehaviourRules {
+	return BehaviourRules{
+		MinFlyTime:  3,
+		MaxFlyTime:  6,
+		MinIdleTime: 1,
+		MaxIdleTime: 2,
+		StunTime:    1,
+		DyingTime:   0.5,
+	}
+}
+
+// Validate returns a description of every problem in the rules, or nothing if they are legal
+func (r BehaviourRules) Validate() []string {
+	problems := []string{}
+	if r.MinFlyTime <= 0 || r.MaxFlyTime < r.MinFlyTime {
+		problems = append(problems, fmt.Sprintf("behaviour fly time range [%v, %v] must be positive and ordered", r.MinFlyTime, r.MaxFlyTime))
+	}
+	if r.MinIdleTime <= 0 || r.MaxIdleTime < r.MinIdleTime {
+		problems = append(problems, fmt.Sprintf("behaviour idle time range [%v, %v] must be positive and ordered", r.MinIdleTime, r.MaxIdleTime))
+	}
+	if r.StunTime < 0 || r.DyingTime < 0 {
+		problems = append(problems, fmt.Sprintf("behaviour stunTime %v and dyingTime %v must not be negative", r.StunTime, r.DyingTime))
+	}
+	return problems
+}
+
+// A brain is everything the OwlSystem keeps about a single owl
+type brain struct {
+	owl     owlEntity
+	machine *fsm.Machine
+	stack   *steering.Stack // the steering behaviours of the current state
+	tint    color.RGBA      // the color of the current state, before the hover highlight
+}
+
+// newBrain builds the state machine for an owl, and starts it flying
+// Each state pushes its own steering behaviours when it's entered, and clears them when it's left
+func (s *OwlSystem) newBrain(owl owlEntity) *brain {
+	b := &brain{
+		owl:     owl,
+		machine: fsm.NewMachine(),
+		stack:   steering.NewStack(s.Steering.MaxSpeed, s.Steering.MaxAcceleration, s.Steering.ReactionTime),
+	}
+
+	b.machine.Add(Flying, fsm.State{
+		Enter: func() {
+			b.tint = color.RGBA{255, 255, 255, 255}
+			if s.Steering.Wander > 0 {
+				b.stack.Push("wander", &steering.Wander{Rand: s.rand, Distance: 60, Radius: 30, Jitter: 4}, s.Steering.Wander)
+			}
+			b.machine.After(between(s.rand, s.Behaviour.MinFlyTime, s.Behaviour.MaxFlyTime), Idle)
+		},
+		Update: func(dt float32) { s.checkWounded(b) },
+		Exit:   b.stack.Clear,
+	})
+	b.machine.Add(Idle, fsm.State{
+		Enter: func() {
+			b.tint = color.RGBA{220, 220, 220, 255}
+			b.stack.Push("hover", &steering.Arrive{Target: steering.Point(center(owl)), SlowingRadius: 50}, 1)
+			b.machine.After(between(s.rand, s.Behaviour.MinIdleTime, s.Behaviour.MaxIdleTime), Flying)
+		},
+		Update: func(dt float32) { s.checkWounded(b) },
+		Exit:   b.stack.Clear,
+	})
+	b.machine.Add(Fleeing, fsm.State{
+		Enter: func() {
+			b.tint = color.RGBA{255, 190, 190, 255}
+			b.stack.Push("flee", &steering.Flee{Target: steering.Cursor{}, PanicDistance: s.Steering.PanicDistance}, s.Steering.Flee)
+		},
+		Update: func(dt float32) {
+			// owls can regenerate, and stop being afraid once they have
+			if owl.HealthComponent().Percent() >= s.Steering.FleeBelow {
+				s.transition(b, Flying)
+			}
+		},
+		Exit: b.stack.Clear,
+	})
+	b.machine.Add(Stunned, fsm.State{
+		Enter: func() {
+			b.tint = color.RGBA{255, 255, 140, 255}
+			b.machine.After(s.Behaviour.StunTime, Flying)
+		},
+	})
+	b.machine.Add(Dying, fsm.State{
+		Enter: func() {
+			b.tint = color.RGBA{255, 255, 255, 255}
+		},
+		Update: func(dt float32) {
+			if b.machine.Elapsed() >= s.Behaviour.DyingTime {
+				s.Prefabs.Despawn(*owl.BasicEntity())
+				return
+			}
+			b.tint.A = uint8(255 * (1 - b.machine.Elapsed()/s.Behaviour.DyingTime))
+		},
+	})
+
+	if err := b.machine.Start(Flying); err != nil {
+		s.Log.Error("Owl state machine failed to start", logging.F{"id": owl.BasicEntity().ID(), "state": Flying, "error": err})
+	}
+	return b
+}
+
+// checkWounded makes the owl flee once it has lost enough health
+func (s *OwlSystem) checkWounded(b *brain) {
+	if b.owl.HealthComponent().Percent() < s.Steering.FleeBelow && s.Steering.Flee > 0 {
+		s.transition(b, Fleeing)
+	}
+}
+
+// transition moves the owl's state machine to the named state, logging it if the machine can't
+func (s *OwlSystem) transition(b *brain, name string) {
+	if err := b.machine.Transition(name); err != nil {
+		s.Log.Error("Owl state machine failed", logging.F{"id": b.owl.BasicEntity().ID(), "state": b.machine.Current(), "next": name, "error": err})
+	}
+}
+
+// between returns a random number between min and max
+func between(r *rand.Rand, min, max float32) float32 {
+	return min + r.Float32()*(max-min)
+}
+
+func center(owl owlEntity) engo.Point {
+	space := owl.SpaceComponent()
+	return engo.Point{space.Position.X + space.Width/2, space.Position.Y + space.Height/2}
+}
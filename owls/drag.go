@@ -0,0 +1,237 @@
+package owls
+
+import (
+	"github.com/engoengine/math"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/physics"
+)
+
+// cursorHistory is how many seconds of cursor movement are used to work out the throw velocity
+const cursorHistory = 0.1
+
+type draggable interface {
+	BasicEntity() *ecs.BasicEntity
+	MouseComponent() *common.MouseComponent
+	ParticleComponent() *physics.ParticleComponent
+}
+
+type cursorSample struct {
+	position engo.Point
+	age      float32 // seconds since the sample was taken
+}
+
+// The DragSystem lets the player grab particles with the mouse and throw them
+// A particle is only grabbed once the cursor moves a little while the button is held on it, so that a click
+// without moving is left as a click. See Dragged
+// A grabbed particle is pulled towards the cursor by a spring in the physics engine, rather than being moved directly,
+// so it still collides with walls on the way. When released it keeps the cursor's recent velocity
+// Every spawned entity that can be clicked and simulated is added automatically
+type DragSystem struct {
+	Engine       *physics.ParticleEngine // the engine that simulates the draggable particles
+	Bus          *events.Bus             // where spawned entities come from
+	Stiffness    float32                 // the spring constant per unit of mass. Higher is snappier
+	MaxThrow     float32                 // the fastest a particle can be thrown, in pixels/second
+	DragDistance float32                 // how far the cursor must move while pressed, in pixels, before the particle is grabbed
+	Log          logging.Logger
+	entities     map[uint64]draggable
+	pressed      draggable               // the entity the button was pressed on, until it's grabbed or released
+	pressedAt    engo.Point              // where the cursor was when the button was pressed
+	gesture      uint64                  // the entity the button was last pressed on
+	dragged      bool                    // whether the entity the button was last pressed on was grabbed
+	held         draggable               // the entity being dragged, or nil
+	spring       *physics.AnchoredSpring // pulls the held entity towards the cursor
+	offset       engo.Point              // from the cursor to the held entity's center when it was grabbed
+	samples      []cursorSample          // recent cursor positions, oldest first
+	paused       bool
+}
+
+// Add adds a new entity to the system
+func (s *DragSystem) Add(entity draggable) {
+	s.entities[entity.BasicEntity().ID()] = entity
+}
+
+// Remove removes an entity from the system, letting go of it if it was held
+func (s *DragSystem) Remove(entity ecs.BasicEntity) {
+	if s.held != nil && s.held.BasicEntity().ID() == entity.ID() {
+		s.release(false)
+	}
+	if s.pressed != nil && s.pressed.BasicEntity().ID() == entity.ID() {
+		s.pressed = nil
+	}
+	delete(s.entities, entity.ID())
+}
+
+// New is called every time the system is added to a world
+func (s *DragSystem) New(world *ecs.World) {
+	if s.Stiffness <= 0 {
+		s.Stiffness = 200
+	}
+	if s.MaxThrow <= 0 {
+		s.MaxThrow = 1500
+	}
+	if s.DragDistance <= 0 {
+		s.DragDistance = 4
+	}
+	if s.entities == nil {
+		s.entities = make(map[uint64]draggable, 10)
+	}
+	s.held = nil
+	s.pressed = nil
+	s.dragged = false
+	s.samples = make([]cursorSample, 0, 16)
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(draggable); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// SetPaused stops or restarts dragging. Anything held is dropped without being thrown
+func (s *DragSystem) SetPaused(paused bool) {
+	s.paused = paused
+	if paused && s.held != nil {
+		s.release(false)
+	}
+	if paused {
+		s.pressed = nil
+	}
+}
+
+// Update grabs, drags and throws entities as the mouse is pressed, moved and released
+func (s *DragSystem) Update(dt float32) {
+	if s.paused {
+		return
+	}
+
+	cursor := engo.Point{engo.Input.Mouse.X, engo.Input.Mouse.Y}
+	s.sample(cursor, dt)
+
+	if s.held == nil {
+		if s.pressed != nil {
+			s.drag(cursor)
+			return
+		}
+		for id, entity := range s.entities {
+			if entity.MouseComponent().Clicked {
+				s.pressed = entity
+				s.pressedAt = cursor
+				s.gesture = id
+				s.dragged = false
+				break
+			}
+		}
+		return
+	}
+
+	if engo.Input.Mouse.Action == engo.Release {
+		s.release(true)
+		return
+	}
+
+	s.spring.Anchor = cursor
+	s.spring.Anchor.Add(s.offset)
+}
+
+// Dragged returns whether the button was last pressed on the entity with the given id, and the press grabbed it
+// rather than just clicking it. It stays true until the button is pressed again, so it can be checked on release
+func (s *DragSystem) Dragged(id uint64) bool {
+	return s.dragged && s.gesture == id
+}
+
+// drag grabs the pressed entity once the cursor has moved far enough, or forgets it if the button is released first
+func (s *DragSystem) drag(cursor engo.Point) {
+	if engo.Input.Mouse.Action == engo.Release {
+		s.pressed = nil
+		return
+	}
+
+	moved := cursor
+	moved.Subtract(s.pressedAt)
+	if _, distance := moved.Normalize(); distance >= s.DragDistance {
+		s.grab(s.pressed, cursor)
+		s.pressed = nil
+	}
+}
+
+func (s *DragSystem) grab(entity draggable, cursor engo.Point) {
+	body := entity.ParticleComponent()
+	if body.InvMass == 0 {
+		return
+	}
+	mass := 1 / body.InvMass
+
+	s.held = entity
+	s.dragged = true
+	s.offset = engo.Point{
+		body.SpaceComponent.Position.X + body.SpaceComponent.Width/2 - cursor.X,
+		body.SpaceComponent.Position.Y + body.SpaceComponent.Height/2 - cursor.Y,
+	}
+
+	// scaling the spring by mass makes every particle feel the same to drag, and critical damping stops it from wobbling
+	springConstant := s.Stiffness * mass
+	s.spring = &physics.AnchoredSpring{
+		Anchor:         engo.Point{cursor.X + s.offset.X, cursor.Y + s.offset.Y},
+		SpringConstant: springConstant,
+		Damping:        2 * math.Sqrt(springConstant*mass),
+	}
+	s.Engine.AddForceGenerator(entity.BasicEntity().ID(), s.spring)
+
+	s.Log.Debug("Grabbed particle", logging.F{"id": entity.BasicEntity().ID()})
+}
+
+// release lets go of the held entity, throwing it with the cursor's velocity if asked to
+func (s *DragSystem) release(throw bool) {
+	id := s.held.BasicEntity().ID()
+	s.Engine.RemoveForceGenerator(id, s.spring)
+
+	if throw {
+		velocity := s.cursorVelocity()
+		if direction, speed := velocity.Normalize(); speed > s.MaxThrow {
+			velocity = direction
+			velocity.MultiplyScalar(s.MaxThrow)
+		}
+		s.held.ParticleComponent().Velocity = velocity
+		s.Log.Debug("Threw particle", logging.F{"id": id, "velocity": velocity})
+	}
+
+	s.held = nil
+	s.spring = nil
+}
+
+// sample records the cursor position, forgetting samples that are too old to matter
+func (s *DragSystem) sample(cursor engo.Point, dt float32) {
+	for i := range s.samples {
+		s.samples[i].age += dt
+	}
+
+	// always keep the newest old sample, so there's a full window to measure against
+	for len(s.samples) > 1 && s.samples[1].age >= cursorHistory {
+		s.samples = s.samples[1:]
+	}
+
+	s.samples = append(s.samples, cursorSample{position: cursor})
+}
+
+// cursorVelocity is the average velocity of the cursor over the recent samples
+func (s *DragSystem) cursorVelocity() engo.Point {
+	if len(s.samples) < 2 {
+		return engo.Point{}
+	}
+
+	oldest := s.samples[0]
+	newest := s.samples[len(s.samples)-1]
+	if oldest.age <= 0 {
+		return engo.Point{}
+	}
+
+	velocity := newest.position
+	velocity.Subtract(oldest.position)
+	velocity.MultiplyScalar(1 / oldest.age)
+	return velocity
+}
@@ -0,0 +1,47 @@
+package owls
+
+import (
+	"fmt"
+)
+
+// SteeringRules configure how owls steer themselves
+type SteeringRules struct {
+	MaxSpeed        float32 `json:"maxSpeed"`        // the fastest an owl ever wants to fly, in pixels/second
+	MaxAcceleration float32 `json:"maxAcceleration"` // the most an owl can accelerate itself, in pixels/second^2
+	ReactionTime    float32 `json:"reactionTime"`    // seconds an owl takes to reach the velocity it wants
+	Wander          float32 `json:"wander"`          // the weight of wandering, which flying owls do. Zero turns it off
+	Flee            float32 `json:"flee"`            // the weight of fleeing the cursor, which wounded owls do
+	FleeBelow       float32 `json:"fleeBelow"`       // owls flee once their health is below this fraction of their max health
+	PanicDistance   float32 `json:"panicDistance"`   // owls only flee the cursor when it is closer than this, in pixels
+}
+
+// DefaultSteeringRules returns the rules used when none are configured
+func DefaultSteeringRules() SteeringRules {
+	return SteeringRules{
+		MaxSpeed:        250,
+		MaxAcceleration: 400,
+		ReactionTime:    0.5,
+		Wander:          0.3,
+		Flee:            1,
+		FleeBelow:       0.5,
+		PanicDistance:   200,
+	}
+}
+
+// Validate returns a description of every problem in the rules, or nothing if they are legal
+func (r SteeringRules) Validate() []string {
+	problems := []string{}
+	if r.MaxSpeed <= 0 || r.MaxAcceleration <= 0 || r.ReactionTime <= 0 {
+		problems = append(problems, fmt.Sprintf("steering maxSpeed %v, maxAcceleration %v and reactionTime %v must be positive", r.MaxSpeed, r.MaxAcceleration, r.ReactionTime))
+	}
+	if r.Wander < 0 || r.Flee < 0 {
+		problems = append(problems, fmt.Sprintf("steering weights must not be negative, got wander %v and flee %v", r.Wander, r.Flee))
+	}
+	if r.FleeBelow < 0 || r.FleeBelow > 1 {
+		problems = append(problems, fmt.Sprintf("steering fleeBelow must be between 0 and 1, got %v", r.FleeBelow))
+	}
+	if r.PanicDistance < 0 {
+		problems = append(problems, fmt.Sprintf("steering panicDistance must not be negative, got %v", r.PanicDistance))
+	}
+	return problems
+}
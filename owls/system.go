@@ -1,98 +1,55 @@
 package owls
 
 import (
-	"image/color"
+	"math/rand"
 
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/health"
 	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/physics"
+	"github.com/bcokert/engo-test/prefabs"
+	"github.com/bcokert/engo-test/scoring"
 
 	"engo.io/ecs"
 	"engo.io/engo"
 	"engo.io/engo/common"
 )
 
-type BasicHealthComponent struct {
-	Health    float32
-	MaxHealth float32
-}
-
-type HealthBarComponent struct {
-	width       float32
-	height      float32
-	position    engo.Point
-	emptySpace  common.SpaceComponent
-	emptyRender common.RenderComponent
-	emptyBasic  ecs.BasicEntity
-	fullSpace   common.SpaceComponent
-	fullRender  common.RenderComponent
-	fullBasic   ecs.BasicEntity
-}
-
-func (c *HealthBarComponent) Update(percent float32, parentPosition engo.Point) {
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
-	}
-
-	position := engo.Point{parentPosition.X, parentPosition.Y - c.height*2}
-
-	c.fullSpace.Width = percent * c.width
-	c.emptySpace.Width = c.width - c.fullSpace.Width
-
-	c.emptySpace.Position = position
-	c.fullSpace.Position = position
-	c.fullSpace.Position.X += c.emptySpace.Width
-}
-
-func NewHealthBarComponent(width, height float32, parentPosition engo.Point) HealthBarComponent {
-	position := engo.Point{parentPosition.X, parentPosition.Y - height*2}
-	return HealthBarComponent{
-		width:       width,
-		height:      height,
-		position:    position,
-		emptySpace:  common.SpaceComponent{Position: position, Width: 0, Height: height},
-		emptyRender: common.RenderComponent{Drawable: common.Rectangle{}, Color: color.RGBA{255, 0, 0, 255}},
-		emptyBasic:  ecs.NewBasic(),
-		fullSpace:   common.SpaceComponent{Position: position, Width: width, Height: height},
-		fullRender:  common.RenderComponent{Drawable: common.Rectangle{}, Color: color.RGBA{0, 255, 0, 255}},
-		fullBasic:   ecs.NewBasic(),
-	}
-}
-
 type owlEntity interface {
+	Archetype() *Archetype
 	BasicEntity() *ecs.BasicEntity
 	RenderComponent() *common.RenderComponent
 	MouseComponent() *common.MouseComponent
-	BasicHealthComponent() *BasicHealthComponent
-	HealthBarComponent() *HealthBarComponent
+	HealthComponent() *health.Component
 	SpaceComponent() *common.SpaceComponent
 }
 
-// The OwlSystem manages a group of owls; their creation, mouse interaction, and so on.
-// Everything but physics related properties are managed by the Owl System.
-// An owl system also automatically adds owls to itself over time
+// The OwlSystem manages a group of owls; their behaviour, appearance, escapes and deaths.
+// Everything but physics and health related properties are managed by the Owl System.
+// Each owl has a state machine that decides how it steers and how it's tinted. Owls are damaged through the
+// health system, are stunned by hard impacts, and fade away before being removed when they die
+// Every spawned owl is added automatically
 type OwlSystem struct {
-	entities map[uint64]owlEntity
-	world    *ecs.World
-	Log      logging.Logger
+	entities  map[uint64]*brain
+	Log       logging.Logger
+	Bus       *events.Bus             // where owls, their damage and their deaths come from, and kills and escapes are published
+	Prefabs   *prefabs.Registry       // despawns owls that die or escape
+	Engine    *physics.ParticleEngine // the engine that the owls' steering forces are applied in
+	Steering  SteeringRules
+	Behaviour BehaviourRules
+	Seed      int64 // seeds the owls' random decisions
+	rand      *rand.Rand
+	paused    bool
 }
 
-// Add adds a new entity to the system
+// Add adds a new entity to the system, and attaches its steering to the physics engine
 func (s *OwlSystem) Add(entity owlEntity) {
-	s.entities[entity.BasicEntity().ID()] = entity
-	healthbar := entity.HealthBarComponent()
-
-	for _, worldSystem := range s.world.Systems() {
-		switch targetSystem := worldSystem.(type) {
-		case *common.RenderSystem:
-			targetSystem.Add(&healthbar.emptyBasic, &healthbar.emptyRender, &healthbar.emptySpace)
-			targetSystem.Add(&healthbar.fullBasic, &healthbar.fullRender, &healthbar.fullSpace)
-		}
-	}
+	b := s.newBrain(entity)
+	s.entities[entity.BasicEntity().ID()] = b
+	s.Engine.AddForceGenerator(entity.BasicEntity().ID(), b.stack)
 }
 
-// Remove removes an entity from the system, by its entity id
+// Remove removes an entity from the system, by its entity id. The engine drops its steering when it is removed from it
 func (s *OwlSystem) Remove(entity ecs.BasicEntity) {
 	if _, ok := s.entities[entity.ID()]; ok {
 		delete(s.entities, entity.ID())
@@ -101,49 +58,82 @@ func (s *OwlSystem) Remove(entity ecs.BasicEntity) {
 
 // New is called every time the system is added to a world
 func (s *OwlSystem) New(world *ecs.World) {
-	s.world = world
+	if s.Steering.ReactionTime <= 0 {
+		s.Steering = DefaultSteeringRules()
+	}
+	if s.Behaviour.MinFlyTime <= 0 {
+		s.Behaviour = DefaultBehaviourRules()
+	}
+	s.rand = rand.New(rand.NewSource(s.Seed))
 
 	if s.entities == nil {
-		s.entities = make(map[uint64]owlEntity, 10)
+		s.entities = make(map[uint64]*brain, 10)
 	}
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if owl, ok := e.Entity.(owlEntity); ok {
+			s.Add(owl)
+		}
+	})
+	health.OnDamaged(s.Bus, s.onDamaged)
+	health.OnDeath(s.Bus, s.onDeath)
+}
+
+// onDamaged stuns owls that the health system publishes as damaged by an impact
+func (s *OwlSystem) onDamaged(damaged health.Damaged) {
+	b, ok := s.entities[damaged.Entity.ID()]
+	if !ok || damaged.Damage.Type != health.Impact || b.machine.Is(Dying) || b.owl.HealthComponent().Dead() {
+		return
+	}
+
+	s.transition(b, Stunned)
+}
+
+// onDeath scores owls that the health system publishes as dead, and starts them dying
+func (s *OwlSystem) onDeath(death health.Death) {
+	b, ok := s.entities[death.Entity.ID()]
+	if !ok {
+		return
+	}
+
+	s.Bus.Publish(scoring.Killed{MaxHealth: b.owl.HealthComponent().MaxHealth, Size: b.owl.SpaceComponent().Width})
+	s.Log.Debug("Owl died", logging.F{"id": death.Entity.ID(), "damage": death.Damage})
+
+	s.transition(b, Dying)
+}
+
+// SetPaused stops or restarts the processing of owls
+func (s *OwlSystem) SetPaused(paused bool) {
+	s.paused = paused
 }
 
-// Update processes the user interactions with owls, updating or removing them as necessary
-// It also internally manages the list of owls, adding them at certain intervals
+// Update removes owls that have escaped, and updates the state and appearance of the rest
 func (s *OwlSystem) Update(dt float32) {
-	for _, owl := range s.entities {
-		health := owl.BasicHealthComponent()
-		healthbar := owl.HealthBarComponent()
-		mouse := owl.MouseComponent()
-
-		// update health, removing if dead
-		if mouse.Clicked {
-			health.Health--
-			if health.Health <= 0 {
-				s.world.RemoveEntity(*owl.BasicEntity())
-				s.world.RemoveEntity(owl.HealthBarComponent().emptyBasic)
-				s.world.RemoveEntity(owl.HealthBarComponent().fullBasic)
-				continue
-			}
-		}
+	if s.paused {
+		return
+	}
+
+	for _, b := range s.entities {
+		owl := b.owl
 
-		// remove owls that have escaped the screen
+		// remove owls that have escaped the screen. Dying owls have already been scored, so they can't escape
 		p := owl.SpaceComponent().Position
 		if p.X < -100 || p.X > engo.GameWidth()+100 || p.Y < -100 || p.Y > engo.GameHeight()+100 {
-			s.world.RemoveEntity(*owl.BasicEntity())
-			s.world.RemoveEntity(owl.HealthBarComponent().emptyBasic)
-			s.world.RemoveEntity(owl.HealthBarComponent().fullBasic)
+			if !b.machine.Is(Dying) {
+				s.Bus.Publish(scoring.Escaped{})
+			}
+			s.Prefabs.Despawn(*owl.BasicEntity())
 			continue
 		}
 
-		// update healthbar based on health
-		percentHealthy := health.Health / health.MaxHealth
-		healthbar.Update(percentHealthy, owl.SpaceComponent().Position)
+		if err := b.machine.Update(dt); err != nil {
+			s.Log.Error("Owl state machine failed", logging.F{"id": owl.BasicEntity().ID(), "state": b.machine.Current(), "error": err})
+		}
 
-		col := color.RGBA{255, 255, 255, 255}
+		col := b.tint
 
-		// if the mouse is over the owl, make it glow a bit blue
-		if mouse.Hovered {
+		// if the mouse is over a living owl, make it glow a bit blue
+		if owl.MouseComponent().Hovered && !b.machine.Is(Dying) {
 			col.R -= 40
 			col.G -= 40
 		}
@@ -0,0 +1,197 @@
+package owls
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/engoengine/math"
+
+	"engo.io/engo"
+)
+
+// A Region is an axis aligned area that owls are spawned within
+type Region struct {
+	Min engo.Point `json:"min"` // the top left corner of the region
+	Max engo.Point `json:"max"` // the bottom right corner of the region
+}
+
+// A Wave is a group of owls that are spawned one after another
+type Wave struct {
+	Count         int      `json:"count"`         // the number of owls in the wave, before difficulty is applied
+	Archetypes    []string `json:"archetypes"`    // the archetypes to pick from by weight. If empty, any archetype can be picked
+	Region        Region   `json:"region"`        // where the owls appear
+	Delay         float32  `json:"delay"`         // seconds between the end of the previous wave and the first owl of this one
+	SpawnInterval float32  `json:"spawnInterval"` // seconds between each owl in the wave, before difficulty is applied
+}
+
+// A WaveSchedule is the scripted list of waves for a level
+type WaveSchedule struct {
+	Waves []Wave  `json:"waves"`
+	Loop  bool    `json:"loop"` // if true, the waves start over from the first once the last is done
+	Ramp  float32 `json:"ramp"` // how much harder each loop is. A ramp of 0.5 means 50% more, faster owls each loop
+}
+
+// Validate returns a description of every problem in the schedule, or nothing if it is legal
+// Archetype names are checked against the given table
+func (s *WaveSchedule) Validate(archetypes *ArchetypeTable) []string {
+	problems := []string{}
+	if len(s.Waves) == 0 {
+		problems = append(problems, "a wave schedule must have at least 1 wave")
+	}
+	if s.Ramp < 0 {
+		problems = append(problems, fmt.Sprintf("wave ramp must not be negative, got %v", s.Ramp))
+	}
+
+	var period float32
+	for i, wave := range s.Waves {
+		if wave.Count <= 0 {
+			problems = append(problems, fmt.Sprintf("waves[%d] count must be positive, got %d", i, wave.Count))
+		}
+		if wave.Delay < 0 || wave.SpawnInterval < 0 {
+			problems = append(problems, fmt.Sprintf("waves[%d] delay %v and spawnInterval %v must not be negative", i, wave.Delay, wave.SpawnInterval))
+		}
+		if wave.Region.Max.X < wave.Region.Min.X || wave.Region.Max.Y < wave.Region.Min.Y {
+			problems = append(problems, fmt.Sprintf("waves[%d] region min %v must be above and left of max %v", i, wave.Region.Min, wave.Region.Max))
+		}
+		for _, name := range wave.Archetypes {
+			if archetypes.Get(name) == nil {
+				problems = append(problems, fmt.Sprintf("waves[%d] has unknown archetype %q", i, name))
+			}
+		}
+		// the interval is only waited between the owls of a wave, not after the last one
+		if wave.Count > 1 {
+			period += float32(wave.Count-1) * wave.SpawnInterval
+		}
+		period += wave.Delay
+	}
+
+	// a looping schedule that takes no time would spawn owls forever within a single update
+	if s.Loop && period <= 0 {
+		problems = append(problems, "a looping wave schedule must take some time, with a delay or a spawnInterval in a wave of more than 1 owl")
+	}
+
+	return problems
+}
+
+// MaxSpawnsPerUpdate is the most owls a single Update returns. Any more that are due are spawned by the following updates,
+// so a huge dt, or a schedule that takes no time, can't stall the game
+const MaxSpawnsPerUpdate = 100
+
+// A Spawn describes a single owl that the WaveScheduler wants to be created
+type Spawn struct {
+	Archetype *Archetype
+	Position  engo.Point // the top left of the owl, somewhere within the wave's region
+	Velocity  engo.Point
+	Scale     float32
+	Health    float32
+	Wave      int // the index of the wave that spawned the owl
+	Loop      int // how many times the schedule had looped when the owl was spawned
+}
+
+// The WaveScheduler decides when and where owls are spawned, according to a WaveSchedule
+// It doesn't create any entities itself, so it can be run without a game by calling Update
+type WaveScheduler struct {
+	schedule   WaveSchedule
+	archetypes *ArchetypeTable
+	rand       *rand.Rand
+	candidates *ArchetypeTable // the archetypes the current wave picks from
+	wave       int             // the index of the current wave
+	loop       int             // the number of times the schedule has started over
+	remaining  int             // owls left to spawn in the current wave
+	timer      float32         // seconds until the next owl is spawned
+	done       bool
+}
+
+// NewWaveScheduler constructs a scheduler that starts at the first wave
+// The schedule must be valid for the given archetype table
+func NewWaveScheduler(schedule WaveSchedule, archetypes *ArchetypeTable, r *rand.Rand) *WaveScheduler {
+	s := &WaveScheduler{
+		schedule:   schedule,
+		archetypes: archetypes,
+		rand:       r,
+	}
+	s.startWave(0)
+	return s
+}
+
+// Update advances the scheduler by dt seconds, returning every owl that should be spawned during that time
+func (s *WaveScheduler) Update(dt float32) []Spawn {
+	spawns := []Spawn{}
+	if s.done {
+		return spawns
+	}
+
+	s.timer -= dt
+	for s.timer <= 0 && !s.done && len(spawns) < MaxSpawnsPerUpdate {
+		spawns = append(spawns, s.spawn())
+		s.remaining--
+
+		if s.remaining > 0 {
+			s.timer += s.schedule.Waves[s.wave].SpawnInterval / s.difficulty()
+			continue
+		}
+
+		// the wave is over, move on to the next one, looping if necessary
+		next := s.wave + 1
+		if next >= len(s.schedule.Waves) {
+			if !s.schedule.Loop {
+				s.done = true
+				break
+			}
+			next = 0
+			s.loop++
+		}
+		timer := s.timer
+		s.startWave(next)
+		s.timer += timer
+	}
+
+	return spawns
+}
+
+// Done returns whether every wave has been spawned. A looping schedule is never done
+func (s *WaveScheduler) Done() bool {
+	return s.done
+}
+
+// Wave returns the index of the current wave and the number of times the schedule has looped
+func (s *WaveScheduler) Wave() (wave, loop int) {
+	return s.wave, s.loop
+}
+
+// difficulty is the multiplier applied to counts, speeds and spawn rates for the current loop
+func (s *WaveScheduler) difficulty() float32 {
+	return 1 + s.schedule.Ramp*float32(s.loop)
+}
+
+func (s *WaveScheduler) startWave(index int) {
+	wave := s.schedule.Waves[index]
+	s.wave = index
+	s.candidates = s.archetypes
+	if len(wave.Archetypes) > 0 {
+		s.candidates = s.archetypes.Subset(wave.Archetypes)
+	}
+	s.remaining = int(math.Ceil(float32(wave.Count) * s.difficulty()))
+	s.timer = wave.Delay
+}
+
+func (s *WaveScheduler) spawn() Spawn {
+	wave := s.schedule.Waves[s.wave]
+
+	archetype := s.candidates.Pick(s.rand)
+	velocity := archetype.RollVelocity(s.rand)
+	velocity.MultiplyScalar(s.difficulty())
+
+	return Spawn{
+		Archetype: archetype,
+		Position: engo.Point{
+			wave.Region.Min.X + s.rand.Float32()*(wave.Region.Max.X-wave.Region.Min.X),
+			wave.Region.Min.Y + s.rand.Float32()*(wave.Region.Max.Y-wave.Region.Min.Y),
+		},
+		Velocity: velocity,
+		Scale:    archetype.RollScale(s.rand),
+		Health:   archetype.RollHealth(s.rand),
+		Wave:     s.wave,
+		Loop:     s.loop,
+	}
+}
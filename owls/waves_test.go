@@ -0,0 +1,102 @@
+package owls
+
+import (
+	"math/rand"
+	"testing"
+
+	"engo.io/engo"
+)
+
+func testRegion() Region {
+	return Region{Min: engo.Point{0, 0}, Max: engo.Point{100, 100}}
+}
+
+func TestWaveScheduleValidateLoopMustTakeTime(t *testing.T) {
+	archetypes := DefaultArchetypeTable()
+	cases := []struct {
+		name  string
+		waves []Wave
+		legal bool
+	}{
+		{"delay", []Wave{{Count: 1, Delay: 1, Region: testRegion()}}, true},
+		{"interval between owls", []Wave{{Count: 2, SpawnInterval: 1, Region: testRegion()}}, true},
+		{"interval after a single owl", []Wave{{Count: 1, SpawnInterval: 1, Region: testRegion()}}, false},
+		{"nothing", []Wave{{Count: 3, Region: testRegion()}}, false},
+		{"delay in a later wave", []Wave{{Count: 1, SpawnInterval: 5, Region: testRegion()}, {Count: 1, Delay: 0.5, Region: testRegion()}}, true},
+	}
+
+	for _, c := range cases {
+		schedule := WaveSchedule{Waves: c.waves, Loop: true}
+		problems := schedule.Validate(archetypes)
+		if legal := len(problems) == 0; legal != c.legal {
+			t.Errorf("%s: expected legal to be %v, got problems %v", c.name, c.legal, problems)
+		}
+	}
+}
+
+func TestWaveSchedulerSpawnsOnTime(t *testing.T) {
+	schedule := WaveSchedule{Waves: []Wave{{Count: 3, Delay: 1, SpawnInterval: 0.5, Region: testRegion()}}}
+	scheduler := NewWaveScheduler(schedule, DefaultArchetypeTable(), rand.New(rand.NewSource(1)))
+
+	steps := []struct {
+		dt     float32
+		spawns int
+	}{
+		{0.75, 0},
+		{0.25, 1}, // the delay is over
+		{0.25, 0},
+		{0.25, 1},
+		{0.5, 1},
+		{10, 0}, // the schedule doesn't loop
+	}
+	for i, step := range steps {
+		if spawns := scheduler.Update(step.dt); len(spawns) != step.spawns {
+			t.Errorf("step %d: expected %d spawns, got %d", i, step.spawns, len(spawns))
+		}
+	}
+	if !scheduler.Done() {
+		t.Errorf("expected the scheduler to be done")
+	}
+}
+
+func TestWaveSchedulerSpawnsWithinRegion(t *testing.T) {
+	region := Region{Min: engo.Point{10, 20}, Max: engo.Point{30, 40}}
+	schedule := WaveSchedule{Waves: []Wave{{Count: 50, Region: region}}}
+	scheduler := NewWaveScheduler(schedule, DefaultArchetypeTable(), rand.New(rand.NewSource(1)))
+
+	spawns := scheduler.Update(0)
+	if len(spawns) != 50 {
+		t.Fatalf("expected 50 spawns, got %d", len(spawns))
+	}
+	for i, spawn := range spawns {
+		p := spawn.Position
+		if p.X < region.Min.X || p.X > region.Max.X || p.Y < region.Min.Y || p.Y > region.Max.Y {
+			t.Errorf("spawn %d at %v is outside the region", i, p)
+		}
+	}
+}
+
+func TestWaveSchedulerLoopsWithRamp(t *testing.T) {
+	schedule := WaveSchedule{Waves: []Wave{{Count: 2, Delay: 1, Region: testRegion()}}, Loop: true, Ramp: 0.5}
+	scheduler := NewWaveScheduler(schedule, DefaultArchetypeTable(), rand.New(rand.NewSource(1)))
+
+	if spawns := scheduler.Update(1); len(spawns) != 2 {
+		t.Errorf("expected the first loop to spawn 2 owls, got %d", len(spawns))
+	}
+	if spawns := scheduler.Update(1); len(spawns) != 3 {
+		t.Errorf("expected the second loop to spawn 3 owls, got %d", len(spawns))
+	}
+	if _, loop := scheduler.Wave(); loop != 2 {
+		t.Errorf("expected to be waiting for loop 2, got %d", loop)
+	}
+}
+
+// A schedule that takes no time is rejected by Validate, but the scheduler must not hang if it's given one anyway
+func TestWaveSchedulerCapsSpawnsPerUpdate(t *testing.T) {
+	schedule := WaveSchedule{Waves: []Wave{{Count: 1, SpawnInterval: 1, Region: testRegion()}}, Loop: true}
+	scheduler := NewWaveScheduler(schedule, DefaultArchetypeTable(), rand.New(rand.NewSource(1)))
+
+	if spawns := scheduler.Update(1); len(spawns) != MaxSpawnsPerUpdate {
+		t.Errorf("expected %d spawns, got %d", MaxSpawnsPerUpdate, len(spawns))
+	}
+}
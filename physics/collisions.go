@@ -1,14 +1,25 @@
 package physics
 
 import (
+	"github.com/engoengine/math"
+
 	"engo.io/engo"
 	"github.com/bcokert/engo-test/logging"
 	"github.com/bcokert/engo-test/metrics"
 )
 
 type Wall struct {
-	P1 engo.Point
-	P2 engo.Point
+	P1 engo.Point `json:"p1"`
+	P2 engo.Point `json:"p2"`
+}
+
+// A Contact records a collision that was resolved, for game logic that reacts to collisions
+type Contact struct {
+	A      uint64     // the id of the primary particle in the collision
+	B      uint64     // the id of the other particle, if there was one
+	Wall   bool       // true if A hit a wall rather than another particle, in which case B is meaningless
+	Speed  float32    // how fast the participants were approaching each other along the normal, in pixels/second
+	Normal engo.Point // from A towards the point of contact
 }
 
 type ParticleCollisionManifold struct {
@@ -20,9 +31,15 @@ type ParticleCollisionManifold struct {
 
 func (e *ParticleEngine) ResolveCollisions() {
 	collisions := e.detectCollisions()
+	metrics.Mark("Engine.Collisions", int64(len(collisions)))
 
-	defer metrics.Timed(metrics.Func("Engine.ResolveCollisions"))
+	defer metrics.End(metrics.Start("Engine.ResolveCollisions"))
 	for _, collision := range collisions {
+		// two particles of infinite mass can't move each other, and dividing the change between them would give NaN
+		if collision.b != nil && collision.a.ParticleComponent().InvMass+collision.b.ParticleComponent().InvMass == 0 {
+			continue
+		}
+
 		restitution := collision.a.ParticleComponent().Restitution
 		if collision.b != nil {
 			if collision.b.ParticleComponent().Restitution < restitution {
@@ -41,6 +58,17 @@ func (e *ParticleEngine) ResolveCollisions() {
 			continue
 		}
 
+		contact := Contact{
+			A:      collision.a.BasicEntity().ID(),
+			Wall:   collision.b == nil,
+			Speed:  separatingVelocity,
+			Normal: collision.contactNormal,
+		}
+		if collision.b != nil {
+			contact.B = collision.b.BasicEntity().ID()
+		}
+		e.contacts = append(e.contacts, contact)
+
 		deltaVelocity := separatingVelocity*-restitution - separatingVelocity
 
 		// divide the deltaVelocity amongst the participants so that each gets velocity
@@ -74,19 +102,17 @@ func (e *ParticleEngine) ResolveCollisions() {
 }
 
 func (e *ParticleEngine) detectCollisions() []*ParticleCollisionManifold {
-	defer metrics.Timed(metrics.Func("Engine.detectCollisions"))
+	defer metrics.End(metrics.Start("Engine.detectCollisions"))
 	collisions := make([]*ParticleCollisionManifold, 0, len(e.ParticleRegistry.particles))
+	particles := make([]particle, 0, len(e.ParticleRegistry.particles))
 
 	// Detect collisions with walls
 	for _, p := range e.ParticleRegistry.particles {
+		particles = append(particles, p)
 		body := p.ParticleComponent()
 		width := body.SpaceComponent.Width
 		height := body.SpaceComponent.Height
-		r := width
-		if height > width {
-			r = height
-		}
-		r = r / 2
+		r := boundingRadius(body)
 
 		for _, wall := range e.walls {
 			// Set P to the center of the entity
@@ -94,20 +120,26 @@ func (e *ParticleEngine) detectCollisions() []*ParticleCollisionManifold {
 			P.X += width / 2
 			P.Y += height / 2
 
-			L := wall.P1         // L = P1
-			L.Subtract(wall.P2)  // L = P1 - P2
-			L, _ = L.Normalize() // L = (P1 - P2).Normalize()
-			PL := P              // PL = P
-			PL.Subtract(wall.P2) // PL = P - P2
+			L := wall.P1               // L = P1
+			L.Subtract(wall.P2)        // L = P1 - P2
+			L, length := L.Normalize() // L = (P1 - P2).Normalize()
+			PL := P                    // PL = P
+			PL.Subtract(wall.P2)       // PL = P - P2
 
+			// walls are segments, so the projection is clamped to the wall's length, and past either end the nearest point is that end
+			// The normal then points from the end to the particle, so it bounces off the corner
 			PLprojL := L
-			PLprojL.MultiplyScalar(engo.DotProduct(PL, L))
+			PLprojL.MultiplyScalar(math.Min(math.Max(engo.DotProduct(PL, L), 0), length))
 			nearestPoint := wall.P2
 			nearestPoint.Add(PLprojL)
 			wallToP := P
 			wallToP.Subtract(nearestPoint)
 
 			normal, distanceToWall := wallToP.Normalize()
+			if distanceToWall == 0 {
+				// the center is right on the wall, so push it out perpendicular to the wall, whichever side that is
+				normal = engo.Point{-L.Y, L.X}
+			}
 			normal.MultiplyScalar(-1) // since we always give the normal from a's perspective (aka a thinks b hit it)
 
 			if distanceToWall <= r {
@@ -126,5 +158,58 @@ func (e *ParticleEngine) detectCollisions() []*ParticleCollisionManifold {
 		}
 	}
 
+	// Detect collisions between particles, treating each as its bounding sphere
+	e.grid.sort(particles)
+	e.grid.pairs(func(i, j int) {
+		p, q := particles[i], particles[j]
+		bodyA := p.ParticleComponent()
+		bodyB := q.ParticleComponent()
+		aToB := center(bodyB)
+		aToB.Subtract(center(bodyA))
+
+		normal, distance := aToB.Normalize()
+		penetration := boundingRadius(bodyA) + boundingRadius(bodyB) - distance
+		if penetration < 0 || distance == 0 {
+			return
+		}
+
+		manifold := ParticleCollisionManifold{
+			a:                p,
+			b:                q,
+			penetrationDepth: penetration,
+			contactNormal:    normal,
+		}
+		collisions = append(collisions, &manifold)
+
+		e.log.Debug("Detected collision", logging.F{"a": p.BasicEntity().ID(), "b": q.BasicEntity().ID(), "manifold": manifold})
+	})
+
 	return collisions
 }
+
+// Contacts returns the collisions resolved since the last call to ClearContacts
+func (e *ParticleEngine) Contacts() []Contact {
+	return e.contacts
+}
+
+// ClearContacts forgets all of the recorded collisions
+func (e *ParticleEngine) ClearContacts() {
+	e.contacts = e.contacts[:0]
+}
+
+// center returns the center of the particle's bounding box
+func center(body *ParticleComponent) engo.Point {
+	return engo.Point{
+		body.SpaceComponent.Position.X + body.SpaceComponent.Width/2,
+		body.SpaceComponent.Position.Y + body.SpaceComponent.Height/2,
+	}
+}
+
+// boundingRadius returns the radius of the sphere used for the particle's collisions
+func boundingRadius(body *ParticleComponent) float32 {
+	r := body.SpaceComponent.Width
+	if body.SpaceComponent.Height > r {
+		r = body.SpaceComponent.Height
+	}
+	return r / 2
+}
@@ -0,0 +1,108 @@
+package physics
+
+import (
+	"testing"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+)
+
+type testParticle struct {
+	basicEntity       ecs.BasicEntity
+	particleComponent ParticleComponent
+}
+
+func (p *testParticle) BasicEntity() *ecs.BasicEntity {
+	return &p.basicEntity
+}
+
+func (p *testParticle) ParticleComponent() *ParticleComponent {
+	return &p.particleComponent
+}
+
+// newTestParticle makes a 10x10 particle, so with a bounding radius of 5, centered on the given point
+func newTestParticle(center engo.Point) *testParticle {
+	position := engo.Point{center.X - 5, center.Y - 5}
+	return &testParticle{basicEntity: ecs.NewBasic(), particleComponent: NewParticleComponent(10, 10, 1, position, engo.Point{})}
+}
+
+func TestDetectCollisionsWithWallSegment(t *testing.T) {
+	cases := []struct {
+		name     string
+		center   engo.Point
+		collides bool
+		normal   engo.Point
+	}{
+		{"along the wall", engo.Point{50, 3}, true, engo.Point{0, -1}},
+		{"other side of the wall", engo.Point{50, -3}, true, engo.Point{0, 1}},
+		{"far from the wall", engo.Point{50, 20}, false, engo.Point{}},
+		{"past the end, in line with the wall", engo.Point{110, 2}, false, engo.Point{}},
+		{"past the start, in line with the wall", engo.Point{-10, -2}, false, engo.Point{}},
+		{"at the corner", engo.Point{104, 3}, true, engo.Point{-0.8, -0.6}},
+	}
+
+	for _, c := range cases {
+		e := NewParticleEngine(engo.Point{}, 1, 0, []Wall{{P1: engo.Point{0, 0}, P2: engo.Point{100, 0}}}, nil, nil)
+		e.Add(newTestParticle(c.center))
+
+		collisions := e.detectCollisions()
+		if collides := len(collisions) == 1; collides != c.collides {
+			t.Errorf("%s: expected collides to be %v, got %d collisions", c.name, c.collides, len(collisions))
+			continue
+		}
+		if !c.collides {
+			continue
+		}
+		normal := collisions[0].contactNormal
+		if !engo.FloatEqual(normal.X, c.normal.X) || !engo.FloatEqual(normal.Y, c.normal.Y) {
+			t.Errorf("%s: expected the normal to be %v, got %v", c.name, c.normal, normal)
+		}
+	}
+}
+
+func TestDetectCollisionsWithCenterOnWall(t *testing.T) {
+	e := NewParticleEngine(engo.Point{}, 1, 0, []Wall{{P1: engo.Point{0, 0}, P2: engo.Point{100, 0}}}, nil, nil)
+	e.Add(newTestParticle(engo.Point{50, 0}))
+
+	collisions := e.detectCollisions()
+	if len(collisions) != 1 {
+		t.Fatalf("expected 1 collision, got %d", len(collisions))
+	}
+	if normal := collisions[0].contactNormal; !engo.FloatEqual(normal.X, 0) || !engo.FloatEqual(normal.Y*normal.Y, 1) {
+		t.Errorf("expected the normal to be perpendicular to the wall, got %v", normal)
+	}
+}
+
+func TestResolveCollisionsBetweenInfiniteMasses(t *testing.T) {
+	e := NewParticleEngine(engo.Point{}, 1, 0, nil, nil, nil)
+	a := &testParticle{basicEntity: ecs.NewBasic(), particleComponent: NewParticleComponent(10, 10, 0, engo.Point{0, 0}, engo.Point{10, 0})}
+	b := &testParticle{basicEntity: ecs.NewBasic(), particleComponent: NewParticleComponent(10, 10, 0, engo.Point{5, 0}, engo.Point{-10, 0})}
+	e.Add(a)
+	e.Add(b)
+
+	e.ResolveCollisions()
+	for _, p := range []*testParticle{a, b} {
+		if v := p.particleComponent.Velocity; v.X != v.X || v.Y != v.Y {
+			t.Errorf("expected the velocity to be a number, got %v", v)
+		}
+	}
+	if a.particleComponent.Velocity != (engo.Point{10, 0}) || b.particleComponent.Velocity != (engo.Point{-10, 0}) {
+		t.Errorf("expected infinite masses to keep their velocities, got %v and %v", a.particleComponent.Velocity, b.particleComponent.Velocity)
+	}
+}
+
+func TestResolveCollisionsWithInfiniteMass(t *testing.T) {
+	e := NewParticleEngine(engo.Point{}, 1, 0, nil, nil, nil)
+	wall := &testParticle{basicEntity: ecs.NewBasic(), particleComponent: NewParticleComponent(10, 10, 0, engo.Point{0, 0}, engo.Point{})}
+	ball := &testParticle{basicEntity: ecs.NewBasic(), particleComponent: NewParticleComponent(10, 10, 1, engo.Point{5, 0}, engo.Point{-10, 0})}
+	e.Add(wall)
+	e.Add(ball)
+
+	e.ResolveCollisions()
+	if wall.particleComponent.Velocity != (engo.Point{}) {
+		t.Errorf("expected the infinite mass not to move, got %v", wall.particleComponent.Velocity)
+	}
+	if ball.particleComponent.Velocity.X <= 0 {
+		t.Errorf("expected the ball to bounce off, got %v", ball.particleComponent.Velocity)
+	}
+}
@@ -12,11 +12,17 @@ type ParticleEngine struct {
 	dampingFactor     float32        // the damping factor that reduces the velocity slightly (sort of like an aerodynamic impulse due to drag) but is primarily for numerical stability
 	log               logging.Logger // engine wide logger
 	walls             []Wall
-	*ParticleRegistry            // stores all particles and efficiently finds them for the collision detector
-	rand              *rand.Rand // used when random numbers are needed
+	sensors           []Sensor
+	sensorContents    map[string]map[uint64]bool  // the ids of the particles inside each sensor, by sensor name
+	forceGenerators   map[uint64][]ForceGenerator // the generators attached to each particle, by particle id
+	forceFields       []ForceField                // applied to every particle they care about, before the generators
+	contacts          []Contact                   // the collisions resolved since they were last cleared
+	*ParticleRegistry                             // stores all particles and efficiently finds them for the collision detector
+	grid              *grid                       // finds the particles close enough to collide, so only those are compared
+	rand              *rand.Rand                  // used when random numbers are needed
 }
 
-func NewParticleEngine(gravity engo.Point, dampingFactor float32, seed int64, walls []Wall, logger logging.Logger) *ParticleEngine {
+func NewParticleEngine(gravity engo.Point, dampingFactor float32, seed int64, walls []Wall, sensors []Sensor, logger logging.Logger) *ParticleEngine {
 	if logger == nil {
 		logger = logging.NewDefaultLogger(logging.INFO)
 	}
@@ -29,17 +35,34 @@ func NewParticleEngine(gravity engo.Point, dampingFactor float32, seed int64, wa
 		walls = []Wall{}
 	}
 
-	logger.Info("Creating new ParticleEngine with safe configuration", logging.F{"gravity": gravity, "dampingFactor": dampingFactor, "walls": walls})
+	if sensors == nil {
+		sensors = []Sensor{}
+	}
+	sensorContents := make(map[string]map[uint64]bool, len(sensors))
+	for _, sensor := range sensors {
+		sensorContents[sensor.Name] = map[uint64]bool{}
+	}
+
+	logger.Info("Creating new ParticleEngine with safe configuration", logging.F{"gravity": gravity, "dampingFactor": dampingFactor, "walls": walls, "sensors": sensors})
 
 	return &ParticleEngine{
-		gravity:       gravity,
-		dampingFactor: dampingFactor,
-		log:           logger,
-		walls:         walls,
+		gravity:         gravity,
+		dampingFactor:   dampingFactor,
+		log:             logger,
+		walls:           walls,
+		sensors:         sensors,
+		sensorContents:  sensorContents,
+		forceGenerators: map[uint64][]ForceGenerator{},
 		ParticleRegistry: &ParticleRegistry{
 			log:       logger,
 			particles: map[uint64]particle{},
 		},
+		grid: newGrid(),
 		rand: rand.New(rand.NewSource(seed)),
 	}
 }
+
+// Walls returns the walls that particles collide with
+func (e *ParticleEngine) Walls() []Wall {
+	return e.walls
+}
@@ -0,0 +1,90 @@
+package physics
+
+import (
+	"engo.io/engo"
+)
+
+// A ForceGenerator adds a force to a particle every simulation step
+// Generators are attached to particles with ParticleEngine.AddForceGenerator, and are applied right before integration
+type ForceGenerator interface {
+	UpdateForce(body *ParticleComponent, dt float32)
+}
+
+// A ForceField adds forces to many particles at once, once per simulation step before the per particle generators
+// It suits forces that depend on several particles at the same time, like flocking, where the work can be shared
+type ForceField interface {
+	UpdateForces(dt float32)
+}
+
+// An AnchoredSpring pulls the center of a particle towards a fixed point, like a spring attached to a wall
+// The anchor can be moved between steps to drag the particle around
+type AnchoredSpring struct {
+	Anchor         engo.Point
+	SpringConstant float32 // force per pixel of stretch
+	Damping        float32 // force per pixel/second of velocity, opposing the particle's motion
+	RestLength     float32 // the length at which the spring exerts no force
+}
+
+// UpdateForce applies Hooke's law (F = -k*x) plus a damping force (F = -c*v) to the particle
+func (s *AnchoredSpring) UpdateForce(body *ParticleComponent, dt float32) {
+	center := body.SpaceComponent.Position
+	center.X += body.SpaceComponent.Width / 2
+	center.Y += body.SpaceComponent.Height / 2
+
+	toAnchor := s.Anchor
+	toAnchor.Subtract(center)
+	direction, length := toAnchor.Normalize()
+
+	force := direction
+	force.MultiplyScalar((length - s.RestLength) * s.SpringConstant)
+
+	damping := body.Velocity
+	damping.MultiplyScalar(-s.Damping)
+	force.Add(damping)
+
+	body.ForceAccumulator.Add(force)
+}
+
+// AddForceGenerator attaches a generator to the particle with the given id
+// The generator is applied every step until it is removed, or the particle is removed from the engine
+func (e *ParticleEngine) AddForceGenerator(id uint64, generator ForceGenerator) {
+	e.forceGenerators[id] = append(e.forceGenerators[id], generator)
+}
+
+// RemoveForceGenerator detaches a generator from the particle with the given id, if it was attached
+func (e *ParticleEngine) RemoveForceGenerator(id uint64, generator ForceGenerator) {
+	generators := e.forceGenerators[id]
+	for i, g := range generators {
+		if g == generator {
+			generators = append(generators[:i], generators[i+1:]...)
+			break
+		}
+	}
+
+	if len(generators) == 0 {
+		delete(e.forceGenerators, id)
+		return
+	}
+	e.forceGenerators[id] = generators
+}
+
+// AddForceField adds a field that is applied every step until it is removed
+func (e *ParticleEngine) AddForceField(field ForceField) {
+	e.forceFields = append(e.forceFields, field)
+}
+
+// RemoveForceField removes a field from the engine, if it was added
+func (e *ParticleEngine) RemoveForceField(field ForceField) {
+	for i, f := range e.forceFields {
+		if f == field {
+			e.forceFields = append(e.forceFields[:i], e.forceFields[i+1:]...)
+			return
+		}
+	}
+}
+
+// Remove removes a particle from the engine, along with any force generators attached to it
+func (e *ParticleEngine) Remove(id uint64) {
+	delete(e.forceGenerators, id)
+	e.ParticleRegistry.Remove(id)
+}
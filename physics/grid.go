@@ -0,0 +1,67 @@
+package physics
+
+import (
+	"github.com/engoengine/math"
+)
+
+// A cell is a square of the grid that particles are sorted into to find the ones near each other
+type cell struct {
+	x, y int32
+}
+
+// A grid is the broadphase of collision detection. Particles are sorted by their centers into cells as wide as
+// the largest bounding sphere, so two particles can only touch if they are in the same or neighbouring cells,
+// and each particle is only compared with the particles in the 9 cells around it, rather than all of them
+type grid struct {
+	cells    map[cell][]int // the indexes of the particles in each cell
+	cellSize float32
+	sorted   []cell // the cell of each particle, by index
+}
+
+func newGrid() *grid {
+	return &grid{
+		cells: make(map[cell][]int, 32),
+	}
+}
+
+// sort puts every particle into the cell its center is in. Particles are identified by their index afterwards
+func (g *grid) sort(particles []particle) {
+	g.cellSize = 1
+	for _, p := range particles {
+		if d := 2 * boundingRadius(p.ParticleComponent()); d > g.cellSize {
+			g.cellSize = d
+		}
+	}
+
+	// cells that stayed empty through the last step are dropped, so the grid doesn't grow as particles move around,
+	// while the rest keep their slices to be refilled
+	for c, indexes := range g.cells {
+		if len(indexes) == 0 {
+			delete(g.cells, c)
+		} else {
+			g.cells[c] = indexes[:0]
+		}
+	}
+	g.sorted = g.sorted[:0]
+	for i, p := range particles {
+		center := center(p.ParticleComponent())
+		c := cell{int32(math.Floor(center.X / g.cellSize)), int32(math.Floor(center.Y / g.cellSize))}
+		g.cells[c] = append(g.cells[c], i)
+		g.sorted = append(g.sorted, c)
+	}
+}
+
+// pairs calls visit once for every pair of sorted particles in the same or neighbouring cells, with i < j
+func (g *grid) pairs(visit func(i, j int)) {
+	for i, c := range g.sorted {
+		for x := c.x - 1; x <= c.x+1; x++ {
+			for y := c.y - 1; y <= c.y+1; y++ {
+				for _, j := range g.cells[cell{x, y}] {
+					if j > i {
+						visit(i, j)
+					}
+				}
+			}
+		}
+	}
+}
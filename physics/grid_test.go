@@ -0,0 +1,73 @@
+package physics
+
+import (
+	"math/rand"
+	"testing"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+)
+
+// randomParticles scatters n particles of random sizes over an 800x600 screen
+func randomParticles(n int, r *rand.Rand) []particle {
+	particles := make([]particle, 0, n)
+	for i := 0; i < n; i++ {
+		size := 5 + r.Float32()*30
+		position := engo.Point{r.Float32() * 800, r.Float32() * 600}
+		particles = append(particles, &testParticle{
+			basicEntity:       ecs.NewBasic(),
+			particleComponent: NewParticleComponent(size, size, 1, position, engo.Point{}),
+		})
+	}
+	return particles
+}
+
+// touching returns whether the bounding spheres of two particles overlap
+func touching(p, q particle) bool {
+	offset := center(q.ParticleComponent())
+	offset.Subtract(center(p.ParticleComponent()))
+	_, distance := offset.Normalize()
+	return distance <= boundingRadius(p.ParticleComponent())+boundingRadius(q.ParticleComponent())
+}
+
+func TestGridFindsEveryTouchingPair(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	g := newGrid()
+
+	// sorting more than once checks that cells are reused and dropped correctly
+	for step := 0; step < 3; step++ {
+		particles := randomParticles(300, r)
+		g.sort(particles)
+
+		found := map[[2]int]bool{}
+		g.pairs(func(i, j int) {
+			if i >= j || found[[2]int{i, j}] {
+				t.Errorf("step %d: pair %d, %d visited out of order or more than once", step, i, j)
+			}
+			found[[2]int{i, j}] = true
+		})
+
+		for i := range particles {
+			for j := i + 1; j < len(particles); j++ {
+				if touching(particles[i], particles[j]) && !found[[2]int{i, j}] {
+					t.Errorf("step %d: touching particles %d and %d were not paired", step, i, j)
+				}
+			}
+		}
+		if len(found) >= len(particles)*(len(particles)-1)/4 {
+			t.Errorf("step %d: expected the grid to rule out most pairs, got %d", step, len(found))
+		}
+	}
+}
+
+func BenchmarkDetectCollisions(b *testing.B) {
+	e := NewParticleEngine(engo.Point{}, 1, 0, nil, nil, nil)
+	for _, p := range randomParticles(500, rand.New(rand.NewSource(1))) {
+		e.Add(p)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.detectCollisions()
+	}
+}
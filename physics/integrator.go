@@ -9,12 +9,23 @@ import (
 )
 
 func (e *ParticleEngine) Integrate(dt float32) {
-	defer metrics.Timed(metrics.Func("Engine.Integrate"))
+	defer metrics.End(metrics.Start("Engine.Integrate"))
+
+	// Let the force fields add their forces to every particle at once
+	for _, field := range e.forceFields {
+		field.UpdateForces(dt)
+	}
+
 	for _, p := range e.ParticleRegistry.particles {
 		body := p.ParticleComponent()
 
 		e.log.Debug("Before Integration", logging.F{"id": p.BasicEntity().ID(), "particleComponent": p.ParticleComponent()})
 
+		// Let the force generators add their forces
+		for _, generator := range e.forceGenerators[p.BasicEntity().ID()] {
+			generator.UpdateForce(body, dt)
+		}
+
 		// Calculate the net force on the object
 		var netF engo.Point
 
@@ -14,16 +14,18 @@ type ParticleRegistry struct {
 }
 
 func (r *ParticleRegistry) Add(p particle) {
-	defer metrics.Timed(metrics.Func("Registry.Add"))
+	defer metrics.End(metrics.Start("Registry.Add"))
 	r.log.Debug("Adding particle to registry", logging.F{"id": p.BasicEntity().ID(), "particleComponent": p.ParticleComponent()})
 	r.particles[p.BasicEntity().ID()] = p
+	metrics.Gauge("Registry.Particles", float64(len(r.particles)))
 }
 
 func (r *ParticleRegistry) Remove(id uint64) {
-	defer metrics.Timed(metrics.Func("Registry.Remove"))
+	defer metrics.End(metrics.Start("Registry.Remove"))
 	p, ok := r.particles[id]
 	if ok {
 		r.log.Debug("Removing particle from registry", logging.F{"id": id, "particleComponent": p.ParticleComponent()})
 		delete(r.particles, id)
+		metrics.Gauge("Registry.Particles", float64(len(r.particles)))
 	}
 }
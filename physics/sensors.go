@@ -0,0 +1,51 @@
+package physics
+
+import (
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/metrics"
+)
+
+// A Sensor is an axis aligned region that logs particles as they enter and leave it
+// Sensors never affect the particles they contain; they only observe them
+type Sensor struct {
+	Name string     `json:"name"`
+	Min  engo.Point `json:"min"` // the top left corner of the region
+	Max  engo.Point `json:"max"` // the bottom right corner of the region
+}
+
+// Contains returns whether the given point is inside the sensor region
+func (s Sensor) Contains(p engo.Point) bool {
+	return p.X >= s.Min.X && p.X <= s.Max.X && p.Y >= s.Min.Y && p.Y <= s.Max.Y
+}
+
+// UpdateSensors updates the contents of every sensor, logging particles as they enter and leave
+func (e *ParticleEngine) UpdateSensors() {
+	defer metrics.End(metrics.Start("Engine.UpdateSensors"))
+	for _, sensor := range e.sensors {
+		contents := e.sensorContents[sensor.Name]
+
+		for id, p := range e.ParticleRegistry.particles {
+			body := p.ParticleComponent()
+			center := body.SpaceComponent.Position
+			center.X += body.SpaceComponent.Width / 2
+			center.Y += body.SpaceComponent.Height / 2
+
+			inside := sensor.Contains(center)
+			if inside && !contents[id] {
+				contents[id] = true
+				e.log.Debug("Particle entered sensor", logging.F{"sensor": sensor.Name, "id": id})
+			} else if !inside && contents[id] {
+				delete(contents, id)
+				e.log.Debug("Particle left sensor", logging.F{"sensor": sensor.Name, "id": id})
+			}
+		}
+
+		// particles that were removed from the engine are no longer in any sensor
+		for id := range contents {
+			if _, ok := e.ParticleRegistry.particles[id]; !ok {
+				delete(contents, id)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package physics
 import (
 	"engo.io/ecs"
 	"engo.io/engo"
+	"github.com/bcokert/engo-test/events"
 	"github.com/bcokert/engo-test/metrics"
 )
 
@@ -19,14 +20,34 @@ type particle interface {
 	ParticleComponent() *ParticleComponent
 }
 
+// CollidedTopic is the topic of Collided events
+const CollidedTopic events.Topic = "physics.Collided"
+
+// A Collided event is published for every contact resolved during a frame, after the frame's simulation is done
+type Collided struct {
+	Contact Contact
+}
+
+func (e Collided) Topic() events.Topic {
+	return CollidedTopic
+}
+
+// OnCollided subscribes a handler to Collided events
+func OnCollided(bus *events.Bus, handler func(Collided)) {
+	bus.Subscribe(CollidedTopic, func(e events.Event) { handler(e.(Collided)) })
+}
+
 // The ParticlePhysicsSystem is the integration point between engo (the game engine) and a ParticleEngine (physics engine)
 // It handles game loop related events (like delta time), and provides the game an interface to the physics engine.
 // It doesn't have any logic for the game except what is needed to manage the physics engine
+// Every spawned entity with a ParticleComponent is added automatically
 type ParticlePhysicsSystem struct {
 	ParticleEngine *ParticleEngine // the physics engine for this system
+	Bus            *events.Bus     // where spawned entities come from, and collisions are published
 	SimulationRate int             // updates per second
 	simulationAcc  float32         // seconds since the last simulation. When greater than simulationStep, simulation occurs
 	simulationStep float32         // seconds per update, the constant step of each simulation step
+	paused         bool            // while paused, no time passes in the simulation
 }
 
 // Priority determines when the system will run relative to other systems, higher meaning sooner
@@ -48,6 +69,17 @@ func (s *ParticlePhysicsSystem) Remove(entity ecs.BasicEntity) {
 func (s *ParticlePhysicsSystem) New(world *ecs.World) {
 	s.simulationAcc = 0
 	s.simulationStep = 1.0 / float32(s.SimulationRate)
+
+	s.Bus.OnEntitySpawned(func(e events.EntitySpawned) {
+		if entity, ok := e.Entity.(particle); ok {
+			s.Add(entity)
+		}
+	})
+}
+
+// SetPaused stops or restarts the simulation. Time spent paused is never simulated
+func (s *ParticlePhysicsSystem) SetPaused(paused bool) {
+	s.paused = paused
 }
 
 // Update is called as often as the game loop can. If the accumulator has accumulated
@@ -56,8 +88,15 @@ func (s *ParticlePhysicsSystem) New(world *ecs.World) {
 // If for some reason the physics simulation takes longer than a step, physics will be simulated a
 // maximum number times, which will have an effect similar to time slowing down
 func (s *ParticlePhysicsSystem) Update(dt float32) {
+	if s.paused {
+		return
+	}
+
 	s.simulationAcc += dt
 
+	// Contacts are kept for one frame, so that every system can look at the collisions from the last frame
+	s.ParticleEngine.ClearContacts()
+
 	// A few tools to help demo the physics
 	if btn := engo.Input.Button("shakeitup"); btn.JustPressed() {
 		for _, e := range s.ParticleEngine.ParticleRegistry.particles {
@@ -83,10 +122,21 @@ func (s *ParticlePhysicsSystem) Update(dt float32) {
 
 	// Simulate physics in steps until we've caught up to real time or hit the limit
 	// Any remainder less than the simulationStep can be interpolated by the renderer
+	// Each step is timed on its own; a deferred End would only run once the whole loop is done
 	for i := 0; s.simulationAcc > s.simulationStep && i < MaxPhysicsIterations; i++ {
-		defer metrics.Timed(metrics.Func("Engine.Total"))
+		span := metrics.Start("Engine.Total")
 		s.simulationAcc -= s.simulationStep
 		s.ParticleEngine.Integrate(s.simulationStep)
 		s.ParticleEngine.ResolveCollisions()
+		s.ParticleEngine.UpdateSensors()
+		metrics.End(span)
+		metrics.Mark("Engine.Steps", 1)
+	}
+
+	// the seconds of simulation still to run. A backlog that keeps growing means the simulation can't keep up with real time
+	metrics.Gauge("Engine.Backlog", float64(s.simulationAcc))
+
+	for _, contact := range s.ParticleEngine.Contacts() {
+		s.Bus.Publish(Collided{Contact: contact})
 	}
 }
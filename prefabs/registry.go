@@ -0,0 +1,78 @@
+package prefabs
+
+import (
+	"fmt"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+)
+
+// Overrides replace a prefab's defaults for a single spawned entity
+// Nil fields keep the prefab's default, so only the fields that matter need to be set, and any value, even zero, can be
+type Overrides struct {
+	Position *engo.Point
+	Velocity *engo.Point
+	Scale    *float32
+	Mass     *float32 // zero is an infinite mass, which never moves
+	Health   *float32
+}
+
+// A Prefab is a template for a kind of entity. It builds a fresh entity with all of its components each time it's called
+// The components an entity has decide which systems it belongs to, since every system adds the spawned
+// entities that have the components it needs
+type Prefab func(overrides Overrides) events.Entity
+
+// A Registry spawns entities from named prefabs, and despawns them again, by publishing them on a world's bus
+// Like a bus, a registry should live exactly as long as the world it serves
+type Registry struct {
+	prefabs map[string]Prefab
+	bus     *events.Bus
+	log     logging.Logger
+}
+
+// NewRegistry constructs a registry with no prefabs, that spawns onto the given bus
+// Entities are registered with the world's systems by publishing them on the bus, and removed from them the same way
+func NewRegistry(bus *events.Bus, log logging.Logger) *Registry {
+	return &Registry{
+		prefabs: make(map[string]Prefab, 8),
+		bus:     bus,
+		log:     log,
+	}
+}
+
+// Register adds a prefab under the given name. Names must be unique
+func (r *Registry) Register(name string, prefab Prefab) error {
+	if _, ok := r.prefabs[name]; ok {
+		return fmt.Errorf("A prefab named %q is already registered", name)
+	}
+	r.prefabs[name] = prefab
+	return nil
+}
+
+// Has returns whether a prefab is registered under the given name
+func (r *Registry) Has(name string) bool {
+	_, ok := r.prefabs[name]
+	return ok
+}
+
+// Spawn builds an entity from the named prefab and registers it with every system that manages entities like it
+func (r *Registry) Spawn(name string, overrides Overrides) (events.Entity, error) {
+	prefab, ok := r.prefabs[name]
+	if !ok {
+		return nil, fmt.Errorf("No prefab named %q is registered", name)
+	}
+
+	entity := prefab(overrides)
+	r.bus.Publish(events.EntitySpawned{Entity: entity})
+	r.log.Debug("Spawned entity", logging.F{"prefab": name, "id": entity.BasicEntity().ID()})
+
+	return entity, nil
+}
+
+// Despawn removes an entity from every system in the world, by publishing that it's gone
+func (r *Registry) Despawn(entity ecs.BasicEntity) {
+	r.bus.Publish(events.EntityRemoved{Entity: entity})
+	r.log.Debug("Despawned entity", logging.F{"id": entity.ID()})
+}
@@ -0,0 +1,73 @@
+package scenes
+
+import (
+	"engo.io/ecs"
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/highscores"
+	"github.com/bcokert/engo-test/logging"
+)
+
+// The names of every scene in the game, as returned by each scene's Type
+const (
+	Title      = "Title"
+	Gameplay   = "OwlClicker"
+	Results    = "Results"
+	HighScores = "HighScores"
+)
+
+// A Session carries the outcome of the most recent game from the gameplay scene to the results scene
+type Session struct {
+	Played bool              // false until a game has been finished
+	Result highscores.Result // the result of the most recent game
+	Rank   int               // the result's rank in the high score table, or 0 if it didn't make it
+}
+
+// Goto changes to the named scene in a fresh world, so that no systems or entities carry over from
+// the last time it was shown. The current scene is hidden first, which is where it should clean up
+// If a bus is given, a SceneChanged event is published on it before anything changes, so the scene's
+// subscribers can clean up while its world still exists
+func Goto(name string, bus *events.Bus, log logging.Logger) {
+	log.Info("Changing scene", logging.F{"scene": name})
+	if bus != nil {
+		from := ""
+		if current := engo.CurrentScene(); current != nil {
+			from = current.Type()
+		}
+		bus.Publish(events.SceneChanged{From: from, To: name})
+	}
+	if err := engo.SetSceneByName(name, true); err != nil {
+		log.Error("Failed to change scene", logging.F{"scene": name, "error": err})
+	}
+}
+
+// RegisterButtons registers the buttons used to move between scenes
+// Buttons stay registered after their scene is gone, so every button has its own key, and no key is used twice
+func RegisterButtons() {
+	engo.Input.RegisterButton("start", engo.Enter)
+	engo.Input.RegisterButton("back", engo.Escape)
+	engo.Input.RegisterButton("highscores", engo.H)
+	engo.Input.RegisterButton("pause", engo.P)
+	engo.Input.RegisterButton("restart", engo.R)
+	engo.Input.RegisterButton("quit", engo.Q)
+}
+
+// A MenuSystem changes scenes when buttons are pressed
+type MenuSystem struct {
+	Log     logging.Logger
+	Bus     *events.Bus       // where scene changes are published. Optional
+	Buttons map[string]string // the scene to go to, by the name of the button that goes there
+}
+
+// Remove does nothing, since the MenuSystem doesn't manage any entities
+func (s *MenuSystem) Remove(entity ecs.BasicEntity) {}
+
+// Update changes to the scene of the first button that was just pressed
+func (s *MenuSystem) Update(dt float32) {
+	for button, scene := range s.Buttons {
+		if engo.Input.Button(button).JustPressed() {
+			Goto(scene, s.Bus, s.Log)
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package highscores
+
+import (
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/highscores"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/scenes"
+	"github.com/bcokert/engo-test/ui"
+)
+
+// The Scene displays the high score table
+type Scene struct {
+	Log  logging.Logger
+	File string // the high score file to display
+}
+
+// Type returns an identifying string for this scene
+func (s *Scene) Type() string {
+	return scenes.HighScores
+}
+
+// Preload runs exactly once before Setup is run, and its results can be used within Setup
+func (s *Scene) Preload() {
+	engo.Files.Load(ui.FontURL)
+}
+
+// Setup loads the high score table and adds a line of text for each row
+func (s *Scene) Setup(world *ecs.World) {
+	world.AddSystem(&common.RenderSystem{})
+	world.AddSystem(&scenes.MenuSystem{
+		Log: s.Log,
+		Buttons: map[string]string{
+			"start": scenes.Title,
+			"back":  scenes.Title,
+		},
+	})
+	scenes.RegisterButtons()
+
+	titleFont, err := ui.NewFont(32, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+	rowFont, err := ui.NewFont(16, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+
+	ui.NewLabel(titleFont, "High Scores", engo.Point{40, 30}).AddToWorld(world)
+
+	table, err := highscores.Load(s.File, highscores.DefaultCapacity, s.Log)
+	if err != nil {
+		s.Log.Error("Failed to load high scores", logging.F{"error": err, "path": s.File})
+		ui.NewLabel(rowFont, "The high scores could not be loaded", engo.Point{40, 100}).AddToWorld(world)
+		return
+	}
+
+	for i, line := range table.Lines() {
+		ui.NewLabel(rowFont, line, engo.Point{40, float32(100 + i*24)}).AddToWorld(world)
+	}
+	if len(table.Results) == 0 {
+		ui.NewLabel(rowFont, "No games have been played yet", engo.Point{40, 124}).AddToWorld(world)
+	}
+	ui.NewLabel(rowFont, "Enter  back", engo.Point{40, engo.GameHeight() - 50}).AddToWorld(world)
+}
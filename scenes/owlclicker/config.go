@@ -0,0 +1,186 @@
+package owlclicker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/health"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/metrics"
+	"github.com/bcokert/engo-test/owls"
+	"github.com/bcokert/engo-test/physics"
+	"github.com/bcokert/engo-test/prefabs"
+	"github.com/bcokert/engo-test/scoring"
+	"github.com/pkg/errors"
+)
+
+// A SceneConfig describes everything needed to set up an owlclicker level
+// It is loaded from a JSON scene file so that levels can be created without recompiling
+type SceneConfig struct {
+	Gravity        engo.Point          `json:"gravity"`        // the gravitational acceleration, in pixels/second^2
+	Damping        float32             `json:"damping"`        // the velocity damping factor, between 0 and 1
+	Seed           int64               `json:"seed"`           // seeds both the physics engine and the owl spawner
+	SimulationRate int                 `json:"simulationRate"` // physics updates per second
+	Archetypes     string              `json:"archetypes"`     // the archetype file to spawn owls from. If empty, the default archetype is used
+	Waves          owls.WaveSchedule   `json:"waves"`          // when, where and which owls are spawned over time
+	Scoring        scoring.Rules       `json:"scoring"`        // how points are awarded and lives are lost
+	Impact         health.ImpactRules  `json:"impact"`         // how collisions damage owls
+	HealthBars     health.BarStyle     `json:"healthBars"`     // how the owls' health bars look
+	Steering       owls.SteeringRules  `json:"steering"`       // how owls steer themselves
+	Behaviour      owls.BehaviourRules `json:"behaviour"`      // how long owls spend in each of their states
+	FrameBudget    metrics.FrameBudget `json:"frameBudget"`    // how long each frame may take before it is warned about
+	ScreenWalls    bool                `json:"screenWalls"`    // if true, walls are added along the 4 edges of the screen
+	Walls          []physics.Wall      `json:"walls"`          // extra walls, in addition to the screen walls
+	Sensors        []physics.Sensor    `json:"sensors"`        // regions that log particles as they enter and leave
+	Owls           []OwlConfig         `json:"owls"`           // owls that exist at the start of the level
+}
+
+// An OwlConfig describes a single owl placed in a level by a scene file
+type OwlConfig struct {
+	Archetype string     `json:"archetype"` // provides the texture and restitution. If empty, the first archetype is used
+	Position  engo.Point `json:"position"`
+	Velocity  engo.Point `json:"velocity"`
+	Scale     float32    `json:"scale"`
+	Mass      float32    `json:"mass"` // if zero, the archetype's mass is used
+	Health    float32    `json:"health"`
+}
+
+// overrides returns the prefab overrides that place the owl as configured
+func (c OwlConfig) overrides() prefabs.Overrides {
+	overrides := prefabs.Overrides{Position: &c.Position, Velocity: &c.Velocity, Scale: &c.Scale, Health: &c.Health}
+	if c.Mass > 0 {
+		overrides.Mass = &c.Mass
+	}
+	return overrides
+}
+
+// DefaultSceneConfig returns the configuration used when no scene file is given
+func DefaultSceneConfig() *SceneConfig {
+	return &SceneConfig{
+		Gravity:        engo.Point{0, 150},
+		Damping:        0.99,
+		Seed:           312,
+		SimulationRate: 60,
+		Waves: owls.WaveSchedule{
+			Waves: []owls.Wave{
+				{
+					Count:  1,
+					Region: owls.Region{Min: engo.Point{0, 0}, Max: engo.Point{engo.GameWidth(), engo.GameHeight()}},
+					Delay:  2,
+				},
+			},
+			Loop: true,
+		},
+		Scoring:     scoring.DefaultRules(),
+		Impact:      health.DefaultImpactRules(),
+		HealthBars:  health.DefaultBarStyle(),
+		Steering:    owls.DefaultSteeringRules(),
+		Behaviour:   owls.DefaultBehaviourRules(),
+		FrameBudget: metrics.DefaultFrameBudget(),
+		ScreenWalls: true,
+		Walls:       []physics.Wall{},
+		Sensors:     []physics.Sensor{},
+		Owls:        []OwlConfig{},
+	}
+}
+
+// LoadSceneConfig reads and validates the scene file at the given path
+// Fields missing from the file keep their default values
+// Every validation problem is logged, and an error is returned if there were any
+func LoadSceneConfig(path string, log logging.Logger) (*SceneConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open scene file")
+	}
+	defer file.Close()
+
+	config := DefaultSceneConfig()
+	if err := json.NewDecoder(file).Decode(config); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse scene file")
+	}
+
+	problems := config.Validate()
+	for _, problem := range problems {
+		log.Error("Invalid scene file", logging.F{"path": path, "problem": problem})
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("Scene file %s has %d problems", path, len(problems))
+	}
+
+	return config, nil
+}
+
+// Validate returns a description of every problem in the config, or nothing if it is legal
+func (c *SceneConfig) Validate() []string {
+	problems := append(c.Scoring.Validate(), c.Impact.Validate()...)
+	problems = append(problems, c.HealthBars.Validate()...)
+	problems = append(problems, c.Steering.Validate()...)
+	problems = append(problems, c.Behaviour.Validate()...)
+	problems = append(problems, c.FrameBudget.Validate()...)
+
+	if c.Damping < 0 || c.Damping > 1 {
+		problems = append(problems, fmt.Sprintf("damping must be between 0 and 1, got %v", c.Damping))
+	}
+	if c.SimulationRate <= 0 {
+		problems = append(problems, fmt.Sprintf("simulationRate must be positive, got %d", c.SimulationRate))
+	}
+	for i, wall := range c.Walls {
+		if wall.P1 == wall.P2 {
+			problems = append(problems, fmt.Sprintf("walls[%d] has the same start and end point %v", i, wall.P1))
+		}
+	}
+	names := make(map[string]bool, len(c.Sensors))
+	for i, sensor := range c.Sensors {
+		if sensor.Name == "" {
+			problems = append(problems, fmt.Sprintf("sensors[%d] has no name", i))
+		}
+		if names[sensor.Name] {
+			problems = append(problems, fmt.Sprintf("sensors[%d] reuses the name %q", i, sensor.Name))
+		}
+		names[sensor.Name] = true
+		if sensor.Min.X >= sensor.Max.X || sensor.Min.Y >= sensor.Max.Y {
+			problems = append(problems, fmt.Sprintf("sensors[%d] min %v must be above and left of max %v", i, sensor.Min, sensor.Max))
+		}
+	}
+	for i, owl := range c.Owls {
+		if owl.Scale <= 0 {
+			problems = append(problems, fmt.Sprintf("owls[%d] scale must be positive, got %v", i, owl.Scale))
+		}
+		if owl.Mass < 0 {
+			problems = append(problems, fmt.Sprintf("owls[%d] mass must not be negative, got %v", i, owl.Mass))
+		}
+		if owl.Health <= 0 {
+			problems = append(problems, fmt.Sprintf("owls[%d] health must be positive, got %v", i, owl.Health))
+		}
+	}
+
+	return problems
+}
+
+// ValidateSpawning returns a description of every problem with the owls and waves in the config,
+// which can only be checked once the archetypes are known
+func (c *SceneConfig) ValidateSpawning(archetypes *owls.ArchetypeTable) []string {
+	problems := c.Waves.Validate(archetypes)
+	for i, owl := range c.Owls {
+		if owl.Archetype != "" && archetypes.Get(owl.Archetype) == nil {
+			problems = append(problems, fmt.Sprintf("owls[%d] has unknown archetype %q", i, owl.Archetype))
+		}
+	}
+	return problems
+}
+
+// AllWalls returns the configured walls, plus the screen walls if enabled
+func (c *SceneConfig) AllWalls() []physics.Wall {
+	walls := make([]physics.Wall, 0, len(c.Walls)+4)
+	if c.ScreenWalls {
+		walls = append(walls,
+			physics.Wall{engo.Point{0, 0}, engo.Point{engo.GameWidth(), 0}},
+			physics.Wall{engo.Point{0, engo.GameHeight()}, engo.Point{engo.GameWidth(), engo.GameHeight()}},
+			physics.Wall{engo.Point{0, 0}, engo.Point{0, engo.GameHeight()}},
+			physics.Wall{engo.Point{engo.GameWidth(), 0}, engo.Point{engo.GameWidth(), engo.GameHeight()}},
+		)
+	}
+	return append(walls, c.Walls...)
+}
@@ -4,17 +4,25 @@ import (
 	"engo.io/ecs"
 	"engo.io/engo"
 	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/flocking"
+	"github.com/bcokert/engo-test/health"
 	"github.com/bcokert/engo-test/owls"
 	"github.com/bcokert/engo-test/physics"
+	"github.com/bcokert/engo-test/prefabs"
 )
 
 type owl struct {
-	basicEntity          ecs.BasicEntity
-	renderComponent      common.RenderComponent
-	mouseComponent       common.MouseComponent
-	particleComponent    physics.ParticleComponent
-	basicHealthComponent owls.BasicHealthComponent
-	healthBarComponent   owls.HealthBarComponent
+	archetype         *owls.Archetype
+	basicEntity       ecs.BasicEntity
+	renderComponent   common.RenderComponent
+	mouseComponent    common.MouseComponent
+	particleComponent physics.ParticleComponent
+	healthComponent   health.Component
+}
+
+func (o *owl) Archetype() *owls.Archetype {
+	return o.archetype
 }
 
 func (o *owl) BasicEntity() *ecs.BasicEntity {
@@ -37,33 +45,64 @@ func (o *owl) SpaceComponent() *common.SpaceComponent {
 	return &o.particleComponent.SpaceComponent
 }
 
-func (o *owl) BasicHealthComponent() *owls.BasicHealthComponent {
-	return &o.basicHealthComponent
+func (o *owl) HealthComponent() *health.Component {
+	return &o.healthComponent
 }
 
-func (o *owl) HealthBarComponent() *owls.HealthBarComponent {
-	return &o.healthBarComponent
+func (o *owl) FlockingWeights() *flocking.Weights {
+	return o.archetype.Flocking
 }
 
-func newOwl(position, velocity engo.Point, texture *common.Texture, scale, mass, health float32) *owl {
+func newOwl(archetype *owls.Archetype, texture *common.Texture, position, velocity engo.Point, scale, mass, maxHealth float32) *owl {
+	particleComponent := physics.NewParticleComponent(
+		texture.Width()*scale,
+		texture.Height()*scale,
+		mass,
+		position,
+		velocity,
+	)
+	particleComponent.Restitution = archetype.Restitution
+
+	healthComponent := health.NewComponent(maxHealth, archetype.Regeneration, archetype.Invulnerability)
+	healthComponent.Resistances[health.Impact] = archetype.Armor
+
 	return &owl{
+		archetype:   archetype,
 		basicEntity: ecs.NewBasic(),
 		renderComponent: common.RenderComponent{
 			Drawable: texture,
 			Scale:    engo.Point{scale, scale},
 		},
-		mouseComponent: common.MouseComponent{},
-		particleComponent: physics.NewParticleComponent(
-			texture.Width()*scale,
-			texture.Height()*scale,
-			mass,
-			position,
-			velocity,
-		),
-		basicHealthComponent: owls.BasicHealthComponent{
-			Health:    health,
-			MaxHealth: health,
-		},
-		healthBarComponent: owls.NewHealthBarComponent(texture.Width()*scale, 6, position),
+		mouseComponent:    common.MouseComponent{},
+		particleComponent: particleComponent,
+		healthComponent:   healthComponent,
+	}
+}
+
+// owlPrefab returns a prefab for owls of the given archetype
+// Owls start still in the top left, in the middle of the archetype's scale and health ranges, unless those are overridden
+func owlPrefab(archetype *owls.Archetype, texture *common.Texture) prefabs.Prefab {
+	return func(overrides prefabs.Overrides) events.Entity {
+		var position, velocity engo.Point
+		if overrides.Position != nil {
+			position = *overrides.Position
+		}
+		if overrides.Velocity != nil {
+			velocity = *overrides.Velocity
+		}
+		scale := (archetype.MinScale + archetype.MaxScale) / 2
+		if overrides.Scale != nil {
+			scale = *overrides.Scale
+		}
+		mass := archetype.Mass
+		if overrides.Mass != nil {
+			mass = *overrides.Mass
+		}
+		maxHealth := float32(archetype.MinHealth+archetype.MaxHealth) / 2
+		if overrides.Health != nil {
+			maxHealth = *overrides.Health
+		}
+
+		return newOwl(archetype, texture, position, velocity, scale, mass, maxHealth)
 	}
 }
@@ -0,0 +1,115 @@
+package owlclicker
+
+import (
+	"fmt"
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/scenes"
+	"github.com/bcokert/engo-test/scoring"
+	"github.com/bcokert/engo-test/ui"
+)
+
+// gameOverDelay is how many seconds the game keeps running after it is over, before the results are shown
+const gameOverDelay = 2
+
+// pausable is implemented by every system that should stop while the game is paused
+type pausable interface {
+	SetPaused(paused bool)
+}
+
+// The flowSystem moves the game in and out of the pause overlay, and leaves the scene when the game is over
+type flowSystem struct {
+	Log           logging.Logger
+	Bus           *events.Bus // where scene changes are published
+	Score         *scoring.ScoreSystem
+	Font          *common.Font
+	world         *ecs.World
+	paused        bool
+	gameOverTimer float32
+	background    *ui.Panel
+	title         *ui.Label
+	details       *ui.Label
+	help          *ui.Label
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+// The flow runs before everything else, so that nothing else updates on the frame the game is paused
+func (s *flowSystem) Priority() int {
+	return 200
+}
+
+// Remove does nothing, since the overlay entities are only removed along with the world
+func (s *flowSystem) Remove(entity ecs.BasicEntity) {}
+
+// New creates the hidden pause overlay
+func (s *flowSystem) New(world *ecs.World) {
+	s.world = world
+	s.paused = false
+	s.gameOverTimer = gameOverDelay
+
+	s.background = ui.NewPanel(color.RGBA{0, 0, 0, 160}, engo.Point{0, 0}, engo.GameWidth(), engo.GameHeight())
+	s.title = ui.NewLabel(s.Font, "Paused", engo.Point{engo.GameWidth()/2 - 60, engo.GameHeight()/2 - 80})
+	s.details = ui.NewLabel(s.Font, "", engo.Point{engo.GameWidth()/2 - 160, engo.GameHeight()/2 - 20})
+	s.help = ui.NewLabel(s.Font, "P resume   R restart   Q quit", engo.Point{engo.GameWidth()/2 - 160, engo.GameHeight()/2 + 20})
+
+	s.background.RenderComponent().SetZIndex(100)
+	s.background.AddToWorld(world)
+	for _, label := range []*ui.Label{s.title, s.details, s.help} {
+		label.RenderComponent().SetZIndex(101)
+		label.AddToWorld(world)
+	}
+	s.showOverlay(false)
+}
+
+// Update handles the pause buttons, and changes to the results once the game has been over for a moment
+func (s *flowSystem) Update(dt float32) {
+	if s.Score.State() == scoring.GameOver {
+		s.gameOverTimer -= dt
+		if s.gameOverTimer <= 0 {
+			scenes.Goto(scenes.Results, s.Bus, s.Log)
+		}
+		return
+	}
+
+	if engo.Input.Button("pause").JustPressed() {
+		s.setPaused(!s.paused)
+		return
+	}
+
+	if !s.paused {
+		return
+	}
+
+	if engo.Input.Button("restart").JustPressed() {
+		scenes.Goto(scenes.Gameplay, s.Bus, s.Log)
+	} else if engo.Input.Button("quit").JustPressed() {
+		scenes.Goto(scenes.Title, s.Bus, s.Log)
+	}
+}
+
+// setPaused pauses or resumes every pausable system in the world, and shows or hides the overlay
+func (s *flowSystem) setPaused(paused bool) {
+	s.paused = paused
+	s.Log.Debug("Setting paused", logging.F{"paused": paused})
+
+	for _, worldSystem := range s.world.Systems() {
+		if target, ok := worldSystem.(pausable); ok {
+			target.SetPaused(paused)
+		}
+	}
+
+	s.details.SetText(fmt.Sprintf("Score %d   Lives %d", s.Score.Score(), s.Score.Lives()))
+	s.showOverlay(paused)
+}
+
+func (s *flowSystem) showOverlay(show bool) {
+	s.background.RenderComponent().Hidden = !show
+	s.title.RenderComponent().Hidden = !show
+	s.details.RenderComponent().Hidden = !show
+	s.help.RenderComponent().Hidden = !show
+}
@@ -0,0 +1,44 @@
+package owlclicker
+
+import (
+	"engo.io/ecs"
+	"github.com/bcokert/engo-test/metrics"
+)
+
+// The frameStartSystem opens a frame on the monitor before any other system updates
+type frameStartSystem struct {
+	Monitor *metrics.FrameMonitor
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+// It runs before everything else, so the whole frame is timed
+func (s *frameStartSystem) Priority() int {
+	return 10000
+}
+
+// Remove does nothing, since the system has no entities
+func (s *frameStartSystem) Remove(entity ecs.BasicEntity) {}
+
+// Update starts timing the frame
+func (s *frameStartSystem) Update(dt float32) {
+	s.Monitor.BeginFrame()
+}
+
+// The frameEndSystem closes the frame on the monitor after every other system, including rendering, has updated
+type frameEndSystem struct {
+	Monitor *metrics.FrameMonitor
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+// It runs after everything else, so the whole frame is timed
+func (s *frameEndSystem) Priority() int {
+	return -10000
+}
+
+// Remove does nothing, since the system has no entities
+func (s *frameEndSystem) Remove(entity ecs.BasicEntity) {}
+
+// Update stops timing the frame, which warns if it's been over budget for too long
+func (s *frameEndSystem) Update(dt float32) {
+	s.Monitor.EndFrame()
+}
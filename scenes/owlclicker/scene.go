@@ -2,83 +2,309 @@ package owlclicker
 
 import (
 	"fmt"
+	"image/color"
 	"time"
 
 	"engo.io/ecs"
 	"engo.io/engo"
 	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/flocking"
+	"github.com/bcokert/engo-test/health"
+	"github.com/bcokert/engo-test/highscores"
 	"github.com/bcokert/engo-test/logging"
 	"github.com/bcokert/engo-test/metrics"
 	"github.com/bcokert/engo-test/owls"
 	"github.com/bcokert/engo-test/physics"
+	"github.com/bcokert/engo-test/prefabs"
+	"github.com/bcokert/engo-test/scenes"
+	"github.com/bcokert/engo-test/scoring"
+	"github.com/bcokert/engo-test/ui"
 )
 
 type Scene struct {
-	Log logging.Logger
+	Log        logging.Logger
+	File       string           // the scene file to load the level from. If empty, the default level is used
+	HighScores string           // the high score file that each session's result is ranked into. If empty, results aren't kept
+	Session    *scenes.Session  // receives the result of each session, for the results scene
+	Metrics    *metrics.Flusher // flushed after each session. If nil, the metrics aren't written
+	config     *SceneConfig
+	archetypes *owls.ArchetypeTable
+	score      *scoring.ScoreSystem
+	frames     *metrics.FrameMonitor
+	finished   bool // whether the current session has been recorded and its metrics flushed
 }
 
 // Type returns an identifying string for this system, primarily to differentiate systems
 func (s *Scene) Type() string {
-	return "OwlClicker"
+	return scenes.Gameplay
 }
 
 // Preload runs exactly once before Setup is run, and its results can be used within Setup
 func (s *Scene) Preload() {
-	engo.Files.Load("textures/owl.png")
+	s.config, s.archetypes = s.load()
+	engo.Files.Load(s.archetypes.Textures()...)
+	engo.Files.Load(ui.FontURL)
+}
+
+// load reads the scene file and the archetype file it refers to, falling back to the default scene if either is invalid
+func (s *Scene) load() (*SceneConfig, *owls.ArchetypeTable) {
+	if s.File == "" {
+		return DefaultSceneConfig(), owls.DefaultArchetypeTable()
+	}
+
+	config, err := LoadSceneConfig(s.File, s.Log)
+	if err != nil {
+		s.Log.Error("Failed to load scene file, using the default scene", logging.F{"error": err, "path": s.File})
+		return DefaultSceneConfig(), owls.DefaultArchetypeTable()
+	}
+
+	archetypes := owls.DefaultArchetypeTable()
+	if config.Archetypes != "" {
+		archetypes, err = owls.LoadArchetypeTable(config.Archetypes, s.Log)
+		if err != nil {
+			s.Log.Error("Failed to load archetype file, using the default scene", logging.F{"error": err, "path": config.Archetypes})
+			return DefaultSceneConfig(), owls.DefaultArchetypeTable()
+		}
+	}
+
+	problems := config.ValidateSpawning(archetypes)
+	for _, problem := range problems {
+		s.Log.Error("Invalid scene file", logging.F{"path": s.File, "problem": problem})
+	}
+	if len(problems) > 0 {
+		s.Log.Error("Scene file has invalid owls or waves, using the default scene", logging.F{"path": s.File})
+		return DefaultSceneConfig(), owls.DefaultArchetypeTable()
+	}
+
+	s.Log.Info("Loaded scene file", logging.F{"path": s.File, "archetypes": len(archetypes.Archetypes)})
+	return config, archetypes
 }
 
 // Setup adds the systems to the world and initializes everything for the game
 func (s *Scene) Setup(world *ecs.World) {
-	owlTexture, err := common.LoadedSprite("textures/owl.png")
+	textures := make(map[string]*common.Texture, len(s.archetypes.Textures()))
+	for _, url := range s.archetypes.Textures() {
+		texture, err := common.LoadedSprite(url)
+		if err != nil {
+			panic("Error loading texture: " + err.Error())
+		}
+		textures[url] = texture
+	}
+	font, err := ui.NewFont(24, color.White)
 	if err != nil {
-		panic("Error loading texture: " + err.Error())
+		panic("Error loading font: " + err.Error())
+	}
+	s.finished = false
+
+	// every world gets its own bus and prefabs, so nothing stays subscribed once the world is gone
+	bus := events.NewBus()
+	events.SubscribeWorld(bus, world)
+	// the session ends as soon as the scene is changed, while the world and its score are still intact
+	bus.OnSceneChanged(func(events.SceneChanged) { s.finish() })
+	registry := prefabs.NewRegistry(bus, s.Log)
+	for i := range s.archetypes.Archetypes {
+		archetype := &s.archetypes.Archetypes[i]
+		if err := registry.Register(archetype.Name, owlPrefab(archetype, textures[archetype.Texture])); err != nil {
+			panic("Error registering owl prefab: " + err.Error())
+		}
 	}
 
+	s.score = &scoring.ScoreSystem{Rules: s.config.Scoring, Log: s.Log, Bus: bus}
+	healthSystem := &health.System{Log: s.Log, Bus: bus}
+	engine := physics.NewParticleEngine(
+		s.config.Gravity,
+		s.config.Damping,
+		s.config.Seed,
+		s.config.AllWalls(),
+		s.config.Sensors,
+		s.Log)
+
+	// every timed function is attributed to the frame it ran in, so slow frames can be explained
+	s.frames = metrics.NewFrameMonitor(s.config.FrameBudget, s.Log)
+	metrics.SetFrameMonitor(s.frames)
+
+	// Priority 10000
+	world.AddSystem(&frameStartSystem{Monitor: s.frames})
+
+	// Priority -10000
+	world.AddSystem(&frameEndSystem{Monitor: s.frames})
+
 	// Priority -1000
-	world.AddSystem(&common.RenderSystem{})
+	renderSystem := &common.RenderSystem{}
+	world.AddSystem(renderSystem)
+	events.SubscribeRenderSystem(bus, renderSystem)
+
+	// Priority 200
+	world.AddSystem(&flowSystem{Log: s.Log, Bus: bus, Score: s.score, Font: font})
 
 	// Priority 100
-	world.AddSystem(&common.MouseSystem{})
+	mouseSystem := &common.MouseSystem{}
+	world.AddSystem(mouseSystem)
+	events.SubscribeMouseSystem(bus, mouseSystem)
+
+	dragSystem := &owls.DragSystem{
+		Engine: engine,
+		Bus:    bus,
+		Log:    s.Log,
+	}
 
 	// Priority 0
+	world.AddSystem(s.score)
 	world.AddSystem(&owls.OwlSystem{
-		Log: s.Log,
+		Log:       s.Log,
+		Bus:       bus,
+		Prefabs:   registry,
+		Engine:    engine,
+		Steering:  s.config.Steering,
+		Behaviour: s.config.Behaviour,
+		Seed:      s.config.Seed,
+	})
+	world.AddSystem(&health.ClickSystem{
+		Health:         healthSystem,
+		Bus:            bus,
+		Drags:          dragSystem,
+		DamagePerClick: 1,
+	})
+	world.AddSystem(&health.ImpactSystem{
+		Health: healthSystem,
+		Bus:    bus,
+		Rules:  s.config.Impact,
+	})
+	world.AddSystem(&flocking.FlockSystem{
+		Engine: engine,
+		Bus:    bus,
+	})
+	world.AddSystem(dragSystem)
+	world.AddSystem(&system{
+		Score:       s.score,
+		Prefabs:     registry,
+		Textures:    textures,
+		Archetypes:  s.archetypes,
+		Seed:        s.config.Seed,
+		Waves:       s.config.Waves,
+		InitialOwls: s.config.Owls,
+		log:         s.Log,
 	})
-	world.AddSystem(&system{OwlTexture: owlTexture, Seed: 312, OwlInterval: 2, log: s.Log})
+
+	// Priority -50
+	world.AddSystem(healthSystem)
 
 	// Priority -100
 	world.AddSystem(&physics.ParticlePhysicsSystem{
-		ParticleEngine: physics.NewParticleEngine(
-			engo.Point{0, 150},
-			0.99,
-			312,
-			[]physics.Wall{
-				physics.Wall{engo.Point{0, 0}, engo.Point{engo.GameWidth(), 0}},
-				physics.Wall{engo.Point{0, engo.GameHeight()}, engo.Point{engo.GameWidth(), engo.GameHeight()}},
-				physics.Wall{engo.Point{0, 0}, engo.Point{0, engo.GameHeight()}},
-				physics.Wall{engo.Point{engo.GameWidth(), 0}, engo.Point{engo.GameWidth(), engo.GameHeight()}},
-			},
-			s.Log),
-		SimulationRate: 60,
+		ParticleEngine: engine,
+		Bus:            bus,
+		SimulationRate: s.config.SimulationRate,
 	})
 
-	// Global Inputs
+	// Priority -500
+	world.AddSystem(&health.BarSystem{Style: s.config.HealthBars, Bus: bus})
+
+	// Global Inputs, none of which share a key with the buttons that move between scenes
 	engo.Input.RegisterButton("shakeitup", engo.Space)
-	engo.Input.RegisterButton("freeze", engo.Enter)
+	engo.Input.RegisterButton("freeze", engo.F)
 	engo.Input.RegisterButton("faster", engo.Equals)
 	engo.Input.RegisterButton("slower", engo.Dash)
+	scenes.RegisterButtons()
+}
+
+// Hide is run when another scene replaces this one, which ends the current session
+func (s *Scene) Hide() {
+	s.finish()
 }
 
 // Exit is run right before closing the game
 func (s *Scene) Exit() {
-	now := time.Now().Local()
-	path := fmt.Sprintf("functionmetrics/owlicker.%s.metrics", now.Format("2006-01-02-15-04-05"))
-	err := metrics.Output(path)
+	s.finish()
+}
+
+// finish records the result of the current session and flushes the metrics, exactly once per session
+func (s *Scene) finish() {
+	if s.finished {
+		return
+	}
+	s.finished = true
+
+	s.recordResult()
+	s.stopFrameMonitor()
+
+	if s.Metrics != nil {
+		if err := s.Metrics.Flush(); err != nil {
+			s.Log.Error("An error ocurred writing the metrics file", logging.F{"error": err, "path": s.Metrics.Path()})
+		} else {
+			s.Log.Info("Flushed metrics file", logging.F{"path": s.Metrics.Path()})
+		}
+	}
+
+	recorder := metrics.EventRecorder()
+	if recorder == nil {
+		return
+	}
+	// the recorder outlives the session, so the next session's trace only has its own events
+	defer recorder.Clear()
+
+	tracePath := fmt.Sprintf("functionmetrics/owlicker.%s.trace.json", time.Now().Local().Format("2006-01-02-15-04-05"))
+	if err := recorder.OutputChromeTrace(tracePath); err != nil {
+		s.Log.Error("An error ocurred writing the trace file", logging.F{"error": err, "path": tracePath})
+		return
+	}
+	s.Log.Info("Created trace file", logging.F{"path": tracePath, "dropped": recorder.Dropped()})
+}
+
+// stopFrameMonitor detaches the session's frame monitor and logs how many of its frames were over budget
+func (s *Scene) stopFrameMonitor() {
+	if s.frames == nil {
+		return
+	}
+	metrics.SetFrameMonitor(nil)
+
+	frames, overBudget := s.frames.Frames()
+	s.Log.Info("Frame budget summary", logging.F{"frames": frames, "overBudget": overBudget, "budget": s.config.FrameBudget.Budget})
+	s.frames = nil
+}
+
+// recordResult ranks the result of this session into the high score table
+func (s *Scene) recordResult() {
+	if s.score == nil {
+		return
+	}
+
+	scene := s.File
+	if scene == "" {
+		scene = "default"
+	}
+	result := highscores.Result{
+		Score:   s.score.Score(),
+		Kills:   s.score.Kills(),
+		Escaped: s.score.Escaped(),
+		Seconds: s.score.Elapsed(),
+		Seed:    s.config.Seed,
+		Scene:   scene,
+		Played:  time.Now(),
+	}
+	if s.Session != nil {
+		*s.Session = scenes.Session{Played: true, Result: result}
+	}
 
+	if s.HighScores == "" {
+		return
+	}
+
+	table, err := highscores.Load(s.HighScores, highscores.DefaultCapacity, s.Log)
 	if err != nil {
-		s.Log.Error("An error ocurred writing the metrics file", logging.F{"error": err, "path": path})
+		s.Log.Error("Failed to load high scores, this session won't be recorded", logging.F{"error": err, "path": s.HighScores})
+		return
+	}
+
+	rank := table.Insert(result)
+	if s.Session != nil {
+		s.Session.Rank = rank
+	}
+
+	if err := table.Save(s.HighScores); err != nil {
+		s.Log.Error("Failed to save high scores", logging.F{"error": err, "path": s.HighScores})
 		return
 	}
 
-	s.Log.Info("Created metrics file", logging.F{"path": path})
+	s.Log.Info("Recorded session result", logging.F{"score": s.score.Score(), "rank": rank, "path": s.HighScores})
 }
@@ -1,11 +1,14 @@
 package owlclicker
 
 import (
+	"fmt"
 	"math/rand"
 
 	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/metrics"
 	"github.com/bcokert/engo-test/owls"
-	"github.com/bcokert/engo-test/physics"
+	"github.com/bcokert/engo-test/prefabs"
+	"github.com/bcokert/engo-test/scoring"
 
 	"engo.io/ecs"
 	"engo.io/engo"
@@ -14,14 +17,20 @@ import (
 
 // Every scene has 1 game system that manages the actual scene and any concrete entities that the other systems manage
 type system struct {
-	Seed          int64
-	OwlTexture    *common.Texture
-	OwlInterval   float32
-	log           logging.Logger
-	timeToNextOwl float32
-	entities      map[uint64]*owl
-	rand          *rand.Rand
-	world         *ecs.World
+	Seed         int64
+	Textures     map[string]*common.Texture // the loaded textures, by url
+	Archetypes   *owls.ArchetypeTable       // the kinds of owls that are spawned, each registered as a prefab by name
+	Waves        owls.WaveSchedule          // the owls that are spawned over time
+	InitialOwls  []OwlConfig                // owls added on the first update, before any are spawned over time
+	Score        *scoring.ScoreSystem       // no more owls are spawned once the game is over
+	Prefabs      *prefabs.Registry          // spawns owls into every system that manages them
+	log          logging.Logger
+	addedInitial bool
+	paused       bool
+	scheduler    *owls.WaveScheduler
+	entities     map[uint64]*owl
+	rand         *rand.Rand
+	world        *ecs.World
 }
 
 // Remove removes a entity from the system, by its entity id
@@ -34,49 +43,79 @@ func (s *system) Remove(entity ecs.BasicEntity) {
 // New is called every time the system is added to a world
 func (s *system) New(world *ecs.World) {
 	s.world = world
-	if s.OwlInterval == 0 {
-		s.OwlInterval = 5
-	}
-	s.timeToNextOwl = 0
+	s.addedInitial = false
 
 	if s.rand == nil {
 		s.rand = rand.New(rand.NewSource(s.Seed))
 	}
+	s.scheduler = owls.NewWaveScheduler(s.Waves, s.Archetypes, s.rand)
 
 	if s.entities == nil {
 		s.entities = make(map[uint64]*owl, 10)
 	}
 }
 
-// Update will periodically add new owls to the game
+// SetPaused stops or restarts the spawning of owls
+func (s *system) SetPaused(paused bool) {
+	s.paused = paused
+}
+
+// Update adds new owls to the game as the waves call for them, until the game is over
 func (s *system) Update(dt float32) {
-	s.timeToNextOwl -= dt
-	if s.timeToNextOwl <= 0 {
-		s.timeToNextOwl = s.OwlInterval
-
-		scale := s.rand.Float32()/2 + 0.25
-		maxWidth := int(engo.GameWidth() - s.OwlTexture.Width()*scale)
-		maxHeight := int(engo.GameHeight() - s.OwlTexture.Height()*scale)
-		position := engo.Point{
-			float32(s.rand.Intn(maxWidth-int(s.OwlTexture.Width()))) + s.OwlTexture.Width(),
-			float32(s.rand.Intn(maxHeight-int(s.OwlTexture.Height()))) + s.OwlTexture.Height(),
-		}
-		velocity := engo.Point{s.rand.Float32()*400 - 200, s.rand.Float32()*200 - 100}
-
-		owl := newOwl(position, velocity, s.OwlTexture, scale, 1, float32(s.rand.Intn(5)+2))
-		s.entities[owl.BasicEntity().ID()] = owl
-
-		for _, worldSystem := range s.world.Systems() {
-			switch targetSystem := worldSystem.(type) {
-			case *common.RenderSystem:
-				targetSystem.Add(owl.BasicEntity(), owl.RenderComponent(), owl.SpaceComponent())
-			case *common.MouseSystem:
-				targetSystem.Add(owl.BasicEntity(), owl.MouseComponent(), owl.SpaceComponent(), nil)
-			case *owls.OwlSystem:
-				targetSystem.Add(owl)
-			case *physics.ParticlePhysicsSystem:
-				targetSystem.Add(owl)
+	if s.paused {
+		return
+	}
+
+	// The other systems aren't guaranteed to have subscribed until after New, so the initial owls are added here
+	if !s.addedInitial {
+		s.addedInitial = true
+		for _, config := range s.InitialOwls {
+			archetype := config.Archetype
+			if archetype == "" {
+				archetype = s.Archetypes.Archetypes[0].Name
 			}
+			s.spawn(archetype, config.overrides())
+		}
+	}
+
+	if s.Score.State() == scoring.GameOver {
+		return
+	}
+
+	for _, spawn := range s.scheduler.Update(dt) {
+		texture := s.Textures[spawn.Archetype.Texture]
+
+		// keep the whole owl on the screen, even if the wave's region reaches the edge
+		position := spawn.Position
+		if maxX := engo.GameWidth() - texture.Width()*spawn.Scale; position.X > maxX {
+			position.X = maxX
 		}
+		if maxY := engo.GameHeight() - texture.Height()*spawn.Scale; position.Y > maxY {
+			position.Y = maxY
+		}
+
+		velocity, scale, health := spawn.Velocity, spawn.Scale, spawn.Health
+		s.spawn(spawn.Archetype.Name, prefabs.Overrides{
+			Position: &position,
+			Velocity: &velocity,
+			Scale:    &scale,
+			Health:   &health,
+		})
+	}
+}
+
+// spawn creates an owl from the named archetype's prefab, and keeps track of it
+func (s *system) spawn(archetype string, overrides prefabs.Overrides) {
+	entity, err := s.Prefabs.Spawn(archetype, overrides)
+	if err != nil {
+		s.log.Error("Failed to spawn owl", logging.F{"archetype": archetype, "error": err})
+		return
+	}
+	o, ok := entity.(*owl)
+	if !ok {
+		s.log.Error("Prefab did not spawn an owl", logging.F{"archetype": archetype, "id": entity.BasicEntity().ID(), "type": fmt.Sprintf("%T", entity)})
+		return
 	}
+	s.entities[o.BasicEntity().ID()] = o
+	metrics.Count("Owls.Spawned", 1)
 }
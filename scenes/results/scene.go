@@ -0,0 +1,74 @@
+package results
+
+import (
+	"fmt"
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/scenes"
+	"github.com/bcokert/engo-test/ui"
+)
+
+// The Scene shows the result of the game that was just played
+type Scene struct {
+	Log     logging.Logger
+	Session *scenes.Session // the session whose result is shown
+}
+
+// Type returns an identifying string for this scene
+func (s *Scene) Type() string {
+	return scenes.Results
+}
+
+// Preload runs exactly once before Setup is run, and its results can be used within Setup
+func (s *Scene) Preload() {
+	engo.Files.Load(ui.FontURL)
+}
+
+// Setup adds the result text and the menu
+func (s *Scene) Setup(world *ecs.World) {
+	world.AddSystem(&common.RenderSystem{})
+	world.AddSystem(&scenes.MenuSystem{
+		Log: s.Log,
+		Buttons: map[string]string{
+			"start":      scenes.Title,
+			"back":       scenes.Title,
+			"restart":    scenes.Gameplay,
+			"highscores": scenes.HighScores,
+		},
+	})
+	scenes.RegisterButtons()
+
+	titleFont, err := ui.NewFont(40, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+	font, err := ui.NewFont(20, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+
+	ui.NewLabel(titleFont, "Game Over", engo.Point{280, 80}).AddToWorld(world)
+
+	lines := []string{"No game has been played yet"}
+	if s.Session != nil && s.Session.Played {
+		result := s.Session.Result
+		lines = []string{
+			fmt.Sprintf("Score     %d", result.Score),
+			fmt.Sprintf("Kills     %d", result.Kills),
+			fmt.Sprintf("Escaped   %d", result.Escaped),
+			fmt.Sprintf("Time      %.1fs", result.Seconds),
+		}
+		if s.Session.Rank > 0 {
+			lines = append(lines, fmt.Sprintf("New high score, rank %d", s.Session.Rank))
+		}
+	}
+	lines = append(lines, "", "Enter  title", "R      play again", "H      high scores")
+
+	for i, line := range lines {
+		ui.NewLabel(font, line, engo.Point{280, float32(180 + i*30)}).AddToWorld(world)
+	}
+}
@@ -0,0 +1,68 @@
+package title
+
+import (
+	"fmt"
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+	"github.com/bcokert/engo-test/highscores"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/scenes"
+	"github.com/bcokert/engo-test/ui"
+)
+
+// The Scene is the first thing shown when the game starts, and leads to the game and the high scores
+type Scene struct {
+	Log        logging.Logger
+	HighScores string // the high score file, used to show the best score. If empty, no best score is shown
+}
+
+// Type returns an identifying string for this scene
+func (s *Scene) Type() string {
+	return scenes.Title
+}
+
+// Preload runs exactly once before Setup is run, and its results can be used within Setup
+func (s *Scene) Preload() {
+	engo.Files.Load(ui.FontURL)
+}
+
+// Setup adds the title text and the menu
+func (s *Scene) Setup(world *ecs.World) {
+	world.AddSystem(&common.RenderSystem{})
+	world.AddSystem(&scenes.MenuSystem{
+		Log: s.Log,
+		Buttons: map[string]string{
+			"start":      scenes.Gameplay,
+			"highscores": scenes.HighScores,
+		},
+	})
+	scenes.RegisterButtons()
+
+	titleFont, err := ui.NewFont(48, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+	menuFont, err := ui.NewFont(20, color.White)
+	if err != nil {
+		panic("Error loading font: " + err.Error())
+	}
+
+	ui.NewLabel(titleFont, "Owl Game", engo.Point{260, 160}).AddToWorld(world)
+	ui.NewLabel(menuFont, "Enter  play", engo.Point{300, 300}).AddToWorld(world)
+	ui.NewLabel(menuFont, "H      high scores", engo.Point{300, 330}).AddToWorld(world)
+
+	if s.HighScores == "" {
+		return
+	}
+	table, err := highscores.Load(s.HighScores, highscores.DefaultCapacity, s.Log)
+	if err != nil {
+		s.Log.Error("Failed to load high scores", logging.F{"error": err, "path": s.HighScores})
+		return
+	}
+	if len(table.Results) > 0 {
+		ui.NewLabel(menuFont, fmt.Sprintf("Best   %d", table.Results[0].Score), engo.Point{300, 400}).AddToWorld(world)
+	}
+}
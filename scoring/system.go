@@ -0,0 +1,256 @@
+package scoring
+
+import (
+	"fmt"
+
+	"engo.io/ecs"
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+	"github.com/bcokert/engo-test/metrics"
+)
+
+const (
+	KilledTopic  events.Topic = "scoring.Killed"
+	EscapedTopic events.Topic = "scoring.Escaped"
+)
+
+// A Killed event is published when the player kills an owl, to award points for it
+type Killed struct {
+	MaxHealth float32
+	Size      float32 // the owl's width, in pixels
+}
+
+func (e Killed) Topic() events.Topic {
+	return KilledTopic
+}
+
+// An Escaped event is published when an owl gets away, to take a life for it
+type Escaped struct{}
+
+func (e Escaped) Topic() events.Topic {
+	return EscapedTopic
+}
+
+// A State is the overall state of a game, as far as scoring is concerned
+type State int
+
+const (
+	Playing  State = iota
+	GameOver State = iota
+)
+
+func (s State) String() string {
+	switch s {
+	case Playing:
+		return "Playing"
+	case GameOver:
+		return "GameOver"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Rules configure how points are awarded and lives are lost
+type Rules struct {
+	Lives              int     `json:"lives"`              // how many owls can escape before the game is over
+	PointsPerHealth    float32 `json:"pointsPerHealth"`    // points awarded for each point of max health a killed owl had
+	ReferenceSize      float32 `json:"referenceSize"`      // the owl width, in pixels, that earns exactly the base points. Smaller owls earn more
+	ComboWindow        float32 `json:"comboWindow"`        // seconds after a kill in which another kill continues the combo
+	ComboStep          float32 `json:"comboStep"`          // how much the multiplier grows for each kill in a combo
+	MaxComboMultiplier float32 `json:"maxComboMultiplier"` // the largest multiplier a combo can reach
+}
+
+// DefaultRules returns the rules used when none are configured
+func DefaultRules() Rules {
+	return Rules{
+		Lives:              5,
+		PointsPerHealth:    10,
+		ReferenceSize:      100,
+		ComboWindow:        1,
+		ComboStep:          0.5,
+		MaxComboMultiplier: 4,
+	}
+}
+
+// Validate returns a description of every problem in the rules, or nothing if they are legal
+func (r Rules) Validate() []string {
+	problems := []string{}
+	if r.Lives <= 0 {
+		problems = append(problems, fmt.Sprintf("lives must be positive, got %d", r.Lives))
+	}
+	if r.PointsPerHealth < 0 {
+		problems = append(problems, fmt.Sprintf("pointsPerHealth must not be negative, got %v", r.PointsPerHealth))
+	}
+	if r.ReferenceSize <= 0 {
+		problems = append(problems, fmt.Sprintf("referenceSize must be positive, got %v", r.ReferenceSize))
+	}
+	if r.ComboWindow < 0 || r.ComboStep < 0 {
+		problems = append(problems, fmt.Sprintf("comboWindow %v and comboStep %v must not be negative", r.ComboWindow, r.ComboStep))
+	}
+	if r.MaxComboMultiplier < 1 {
+		problems = append(problems, fmt.Sprintf("maxComboMultiplier must be at least 1, got %v", r.MaxComboMultiplier))
+	}
+	return problems
+}
+
+// The ScoreSystem keeps track of the player's score, combo and lives
+// Other systems publish kills and escapes to it, and it decides when the game is over
+type ScoreSystem struct {
+	Rules     Rules
+	Log       logging.Logger
+	Bus       *events.Bus // where kills and escapes come from
+	state     State
+	score     int64
+	lives     int
+	kills     int
+	escaped   int
+	combo     int     // the number of kills in the current combo
+	comboTime float32 // seconds left before the current combo ends
+	elapsed   float32 // seconds spent playing
+	paused    bool
+}
+
+// Priority determines when the system will run relative to other systems, higher meaning sooner
+func (s *ScoreSystem) Priority() int {
+	return 0
+}
+
+// Remove does nothing, since the ScoreSystem doesn't manage any entities
+func (s *ScoreSystem) Remove(entity ecs.BasicEntity) {}
+
+// New is called every time the system is added to a world, and starts a fresh game
+func (s *ScoreSystem) New(world *ecs.World) {
+	s.state = Playing
+	s.score = 0
+	s.lives = s.Rules.Lives
+	s.kills = 0
+	s.escaped = 0
+	s.combo = 0
+	s.comboTime = 0
+	s.elapsed = 0
+
+	s.Bus.Subscribe(KilledTopic, func(e events.Event) {
+		killed := e.(Killed)
+		s.Kill(killed.MaxHealth, killed.Size)
+	})
+	s.Bus.Subscribe(EscapedTopic, func(e events.Event) {
+		s.Escape()
+	})
+}
+
+// SetPaused stops or restarts the clock, so that time spent paused doesn't end combos or count as playing
+func (s *ScoreSystem) SetPaused(paused bool) {
+	s.paused = paused
+}
+
+// Update counts down the current combo
+func (s *ScoreSystem) Update(dt float32) {
+	if s.state != Playing || s.paused {
+		return
+	}
+
+	s.elapsed += dt
+	if s.combo > 0 {
+		s.comboTime -= dt
+		if s.comboTime <= 0 {
+			s.Log.Debug("Combo ended", logging.F{"combo": s.combo})
+			s.combo = 0
+		}
+	}
+}
+
+// Kill awards points for killing an owl with the given max health and width
+// Tougher and smaller owls are worth more, and kills in quick succession build a combo multiplier
+// It returns the points awarded
+func (s *ScoreSystem) Kill(maxHealth, size float32) int64 {
+	if s.state != Playing {
+		return 0
+	}
+
+	sizeFactor := float32(2)
+	if size > 0 {
+		sizeFactor = clamp(s.Rules.ReferenceSize/size, 0.5, 2)
+	}
+
+	s.combo++
+	s.comboTime = s.Rules.ComboWindow
+	points := int64(s.Rules.PointsPerHealth * maxHealth * sizeFactor * s.Multiplier())
+
+	s.score += points
+	s.kills++
+	metrics.Count("Owls.Killed", 1)
+	s.Log.Debug("Owl killed", logging.F{"points": points, "score": s.score, "combo": s.combo})
+
+	return points
+}
+
+// Escape takes a life for an owl that got away, ending the game if there are none left
+func (s *ScoreSystem) Escape() {
+	if s.state != Playing {
+		return
+	}
+
+	s.escaped++
+	metrics.Count("Owls.Escaped", 1)
+	s.lives--
+	s.combo = 0
+	s.Log.Debug("Owl escaped", logging.F{"lives": s.lives})
+
+	if s.lives <= 0 {
+		s.state = GameOver
+		s.Log.Info("Game over", logging.F{"score": s.score, "kills": s.kills, "escaped": s.escaped, "seconds": s.elapsed})
+	}
+}
+
+// Multiplier returns the score multiplier of the current combo
+func (s *ScoreSystem) Multiplier() float32 {
+	if s.combo <= 1 {
+		return 1
+	}
+	return clamp(1+s.Rules.ComboStep*float32(s.combo-1), 1, s.Rules.MaxComboMultiplier)
+}
+
+// State returns whether the game is still being played
+func (s *ScoreSystem) State() State {
+	return s.state
+}
+
+// Score returns the total points awarded so far
+func (s *ScoreSystem) Score() int64 {
+	return s.score
+}
+
+// Lives returns the number of owls that can still escape before the game is over
+func (s *ScoreSystem) Lives() int {
+	return s.lives
+}
+
+// Combo returns the number of kills in the current combo
+func (s *ScoreSystem) Combo() int {
+	return s.combo
+}
+
+// Kills returns the number of owls killed so far
+func (s *ScoreSystem) Kills() int {
+	return s.kills
+}
+
+// Escaped returns the number of owls that have escaped so far
+func (s *ScoreSystem) Escaped() int {
+	return s.escaped
+}
+
+// Elapsed returns the number of seconds played, not counting time after the game ended
+func (s *ScoreSystem) Elapsed() float32 {
+	return s.elapsed
+}
+
+func clamp(x, min, max float32) float32 {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
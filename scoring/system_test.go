@@ -0,0 +1,142 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/bcokert/engo-test/events"
+	"github.com/bcokert/engo-test/logging"
+)
+
+// newTestSystem starts a fresh game with a short combo window, on its own bus
+func newTestSystem() *ScoreSystem {
+	s := &ScoreSystem{
+		Rules: Rules{
+			Lives:              3,
+			PointsPerHealth:    10,
+			ReferenceSize:      100,
+			ComboWindow:        1,
+			ComboStep:          0.5,
+			MaxComboMultiplier: 2,
+		},
+		Log: logging.NewDefaultLogger(logging.INFO),
+		Bus: events.NewBus(),
+	}
+	s.New(nil)
+	return s
+}
+
+// A step publishes an event, if there is one, and then updates the system for dt seconds, if any
+type step struct {
+	event events.Event
+	dt    float32
+}
+
+func kill(maxHealth, size float32) step {
+	return step{event: Killed{MaxHealth: maxHealth, Size: size}}
+}
+
+func escape() step {
+	return step{event: Escaped{}}
+}
+
+func wait(dt float32) step {
+	return step{dt: dt}
+}
+
+func TestScoreSystem(t *testing.T) {
+	cases := []struct {
+		name       string
+		steps      []step
+		score      int64
+		combo      int
+		multiplier float32
+		lives      int
+		state      State
+	}{
+		{"nothing", nil, 0, 0, 1, 3, Playing},
+		{"single kill", []step{kill(1, 100)}, 10, 1, 1, 3, Playing},
+		{"tougher owls are worth more", []step{kill(3, 100)}, 30, 1, 1, 3, Playing},
+		{"smaller owls are worth more", []step{kill(1, 50)}, 20, 1, 1, 3, Playing},
+		{"larger owls are worth less, to a point", []step{kill(1, 400)}, 5, 1, 1, 3, Playing},
+		{"owls without a size are worth the most", []step{kill(1, 0)}, 20, 1, 1, 3, Playing},
+		{"combo", []step{kill(1, 100), wait(0.5), kill(1, 100), wait(0.5), kill(1, 100)}, 10 + 15 + 20, 3, 2, 3, Playing},
+		{"combo multiplier is capped", []step{kill(1, 100), kill(1, 100), kill(1, 100), kill(1, 100)}, 10 + 15 + 20 + 20, 4, 2, 3, Playing},
+		{"combo window ends", []step{kill(1, 100), wait(0.5), wait(0.5), kill(1, 100)}, 10 + 10, 1, 1, 3, Playing},
+		{"each kill restarts the window", []step{kill(1, 100), wait(0.75), kill(1, 100), wait(0.75), kill(1, 100)}, 10 + 15 + 20, 3, 2, 3, Playing},
+		{"escape takes a life", []step{escape()}, 0, 0, 1, 2, Playing},
+		{"escape ends the combo", []step{kill(1, 100), kill(1, 100), escape(), kill(1, 100)}, 10 + 15 + 10, 1, 1, 2, Playing},
+		{"game over when out of lives", []step{escape(), escape(), escape()}, 0, 0, 1, 0, GameOver},
+		{"nothing counts after game over", []step{kill(1, 100), escape(), escape(), escape(), kill(1, 100), escape()}, 10, 0, 1, 0, GameOver},
+	}
+
+	for _, c := range cases {
+		s := newTestSystem()
+		for _, step := range c.steps {
+			if step.event != nil {
+				s.Bus.Publish(step.event)
+			}
+			if step.dt > 0 {
+				s.Update(step.dt)
+			}
+		}
+
+		if s.Score() != c.score || s.Combo() != c.combo || s.Multiplier() != c.multiplier {
+			t.Errorf("%s: expected score %d with a combo of %d at %vx, got %d with %d at %vx", c.name, c.score, c.combo, c.multiplier, s.Score(), s.Combo(), s.Multiplier())
+		}
+		if s.Lives() != c.lives || s.State() != c.state {
+			t.Errorf("%s: expected %d lives and %v, got %d and %v", c.name, c.lives, c.state, s.Lives(), s.State())
+		}
+	}
+}
+
+func TestScoreSystemCounts(t *testing.T) {
+	s := newTestSystem()
+	for _, step := range []step{kill(1, 100), wait(0.5), escape(), kill(1, 100), wait(0.5), escape(), escape(), wait(0.5), kill(1, 100)} {
+		if step.event != nil {
+			s.Bus.Publish(step.event)
+		}
+		if step.dt > 0 {
+			s.Update(step.dt)
+		}
+	}
+
+	if s.Kills() != 2 || s.Escaped() != 3 {
+		t.Errorf("expected 2 kills and 3 escapes, got %d and %d", s.Kills(), s.Escaped())
+	}
+	if s.Elapsed() != 1 {
+		t.Errorf("expected the clock to stop at game over after 1 second, got %v", s.Elapsed())
+	}
+}
+
+func TestScoreSystemPaused(t *testing.T) {
+	s := newTestSystem()
+	s.Bus.Publish(Killed{MaxHealth: 1, Size: 100})
+
+	s.SetPaused(true)
+	s.Update(5)
+	if s.Combo() != 1 || s.Elapsed() != 0 {
+		t.Errorf("expected the clock to stop while paused, got a combo of %d after %v seconds", s.Combo(), s.Elapsed())
+	}
+
+	s.SetPaused(false)
+	s.Update(1)
+	if s.Combo() != 0 || s.Elapsed() != 1 {
+		t.Errorf("expected the combo to end once unpaused, got a combo of %d after %v seconds", s.Combo(), s.Elapsed())
+	}
+}
+
+func TestScoreSystemNewGame(t *testing.T) {
+	s := newTestSystem()
+	for i := 0; i < 3; i++ {
+		s.Escape()
+	}
+	s.Kill(1, 100)
+
+	// a new world's bus starts the game over
+	s.Bus = events.NewBus()
+	s.New(nil)
+	s.Bus.Publish(Killed{MaxHealth: 1, Size: 100})
+	if s.State() != Playing || s.Lives() != 3 || s.Score() != 10 || s.Escaped() != 0 {
+		t.Errorf("expected a fresh game, got %v with %d lives, %d points and %d escaped", s.State(), s.Lives(), s.Score(), s.Escaped())
+	}
+}
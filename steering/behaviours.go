@@ -0,0 +1,148 @@
+package steering
+
+import (
+	"math/rand"
+
+	"github.com/engoengine/math"
+
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/physics"
+)
+
+// A Behaviour decides how a particle wants to change its velocity
+// Steer returns the change in velocity, in pixels/second, that would get the particle moving the way it wants
+// right away, given that it can't go faster than maxSpeed. A zero change means the behaviour is satisfied
+type Behaviour interface {
+	Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point
+}
+
+// Seek steers straight towards a target at full speed
+type Seek struct {
+	Target Target
+}
+
+func (b *Seek) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	return towards(body, b.Target.Position(), maxSpeed)
+}
+
+// Flee steers straight away from a target at full speed, while it is within the panic distance
+type Flee struct {
+	Target        Target
+	PanicDistance float32 // targets further than this, in pixels, are ignored. If zero, the target is always fled
+}
+
+func (b *Flee) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	return away(body, b.Target.Position(), maxSpeed, b.PanicDistance)
+}
+
+// Arrive steers towards a target, slowing down to stop on it rather than overshooting
+type Arrive struct {
+	Target        Target
+	SlowingRadius float32 // the distance from the target, in pixels, at which the particle starts slowing down
+}
+
+func (b *Arrive) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	offset := b.Target.Position()
+	offset.Subtract(center(body))
+	direction, distance := offset.Normalize()
+
+	speed := maxSpeed
+	if distance < b.SlowingRadius {
+		speed = maxSpeed * distance / b.SlowingRadius
+	}
+
+	desired := direction
+	desired.MultiplyScalar(speed)
+	desired.Subtract(body.Velocity)
+	return desired
+}
+
+// Pursue seeks where a moving target will be, rather than where it is
+type Pursue struct {
+	Target        Target
+	MaxPrediction float32 // the furthest ahead, in seconds, that the target's position is predicted
+}
+
+func (b *Pursue) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	return towards(body, predict(body, b.Target, maxSpeed, b.MaxPrediction), maxSpeed)
+}
+
+// Evade flees from where a moving target will be, rather than where it is
+type Evade struct {
+	Target        Target
+	MaxPrediction float32 // the furthest ahead, in seconds, that the target's position is predicted
+	PanicDistance float32 // targets further than this, in pixels, are ignored. If zero, the target is always evaded
+}
+
+func (b *Evade) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	return away(body, predict(body, b.Target, maxSpeed, b.MaxPrediction), maxSpeed, b.PanicDistance)
+}
+
+// Wander steers towards a point that drifts randomly around a circle in front of the particle,
+// which makes for smooth, aimless movement
+type Wander struct {
+	Rand     *rand.Rand
+	Distance float32 // how far ahead of the particle the circle is, in pixels
+	Radius   float32 // the radius of the circle, in pixels
+	Jitter   float32 // the most the point can move around the circle, in radians/second
+	angle    float32 // where the point currently is on the circle
+}
+
+func (b *Wander) Steer(body *physics.ParticleComponent, maxSpeed, dt float32) engo.Point {
+	b.angle += (b.Rand.Float32()*2 - 1) * b.Jitter * dt
+
+	heading, speed := body.Velocity.Normalize()
+	if speed == 0 {
+		heading = engo.Point{1, 0}
+	}
+
+	target := heading
+	target.MultiplyScalar(b.Distance)
+	target.Add(engo.Point{math.Cos(b.angle) * b.Radius, math.Sin(b.angle) * b.Radius})
+	target.Add(center(body))
+
+	return towards(body, target, maxSpeed)
+}
+
+// towards returns the change in velocity needed to head straight for the point at full speed
+func towards(body *physics.ParticleComponent, point engo.Point, maxSpeed float32) engo.Point {
+	desired := point
+	desired.Subtract(center(body))
+	desired, _ = desired.Normalize()
+	desired.MultiplyScalar(maxSpeed)
+	desired.Subtract(body.Velocity)
+	return desired
+}
+
+// away returns the change in velocity needed to head straight away from the point at full speed,
+// or nothing if the point is further than the panic distance
+func away(body *physics.ParticleComponent, point engo.Point, maxSpeed, panicDistance float32) engo.Point {
+	desired := center(body)
+	desired.Subtract(point)
+	desired, distance := desired.Normalize()
+	if panicDistance > 0 && distance > panicDistance {
+		return engo.Point{}
+	}
+
+	desired.MultiplyScalar(maxSpeed)
+	desired.Subtract(body.Velocity)
+	return desired
+}
+
+// predict returns where the target will be by the time the particle could reach it, up to maxPrediction seconds ahead
+func predict(body *physics.ParticleComponent, target Target, maxSpeed, maxPrediction float32) engo.Point {
+	position := target.Position()
+	offset := position
+	offset.Subtract(center(body))
+	_, distance := offset.Normalize()
+
+	prediction := maxPrediction
+	if maxSpeed > 0 && distance/maxSpeed < prediction {
+		prediction = distance / maxSpeed
+	}
+
+	velocity := target.Velocity()
+	velocity.MultiplyScalar(prediction)
+	position.Add(velocity)
+	return position
+}
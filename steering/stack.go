@@ -0,0 +1,102 @@
+package steering
+
+import (
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/physics"
+)
+
+// An entry is a single named behaviour in a stack
+type entry struct {
+	name      string
+	behaviour Behaviour
+	weight    float32
+}
+
+// A Stack combines behaviours into a single force for the physics engine, as a ForceGenerator
+// Behaviours pushed later are on top, and get first claim on the particle's acceleration. Each behaviour's
+// weighted acceleration is added until the maximum is used up, so whatever is on top wins when they disagree,
+// and the behaviours underneath only fill in what is left
+type Stack struct {
+	MaxSpeed        float32 // the fastest the behaviours ever want to go, in pixels/second
+	MaxAcceleration float32 // the most acceleration all the behaviours can add together, in pixels/second^2
+	ReactionTime    float32 // how many seconds a behaviour takes to make the velocity change it wants
+	entries         []entry
+}
+
+// NewStack constructs an empty stack with the given limits
+func NewStack(maxSpeed, maxAcceleration, reactionTime float32) *Stack {
+	return &Stack{
+		MaxSpeed:        maxSpeed,
+		MaxAcceleration: maxAcceleration,
+		ReactionTime:    reactionTime,
+		entries:         []entry{},
+	}
+}
+
+// Push adds a behaviour to the top of the stack, replacing any behaviour already using the name
+func (s *Stack) Push(name string, behaviour Behaviour, weight float32) {
+	s.Remove(name)
+	s.entries = append(s.entries, entry{name: name, behaviour: behaviour, weight: weight})
+}
+
+// Remove takes the named behaviour out of the stack, if it is in it
+func (s *Stack) Remove(name string) {
+	for i, e := range s.entries {
+		if e.name == name {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Has returns whether the named behaviour is in the stack
+func (s *Stack) Has(name string) bool {
+	for _, e := range s.entries {
+		if e.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes every behaviour from the stack
+func (s *Stack) Clear() {
+	s.entries = s.entries[:0]
+}
+
+// UpdateForce adds the force that the behaviours want to the particle
+func (s *Stack) UpdateForce(body *physics.ParticleComponent, dt float32) {
+	if body.InvMass == 0 {
+		return
+	}
+
+	acceleration := s.Acceleration(body, dt)
+
+	// F = m*a
+	acceleration.MultiplyScalar(1 / body.InvMass)
+	body.ForceAccumulator.Add(acceleration)
+}
+
+// Acceleration returns the combined acceleration that the behaviours want, from the top of the stack down
+func (s *Stack) Acceleration(body *physics.ParticleComponent, dt float32) engo.Point {
+	var total engo.Point
+	remaining := s.MaxAcceleration
+
+	for i := len(s.entries) - 1; i >= 0 && remaining > 0; i-- {
+		e := s.entries[i]
+		acceleration := e.behaviour.Steer(body, s.MaxSpeed, dt)
+		acceleration.MultiplyScalar(e.weight / s.ReactionTime)
+
+		direction, length := acceleration.Normalize()
+		if length > remaining {
+			acceleration = direction
+			acceleration.MultiplyScalar(remaining)
+			length = remaining
+		}
+
+		total.Add(acceleration)
+		remaining -= length
+	}
+
+	return total
+}
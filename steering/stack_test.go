@@ -0,0 +1,93 @@
+package steering
+
+import (
+	"testing"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/physics"
+)
+
+type testParticle struct {
+	basicEntity       ecs.BasicEntity
+	particleComponent physics.ParticleComponent
+}
+
+func (p *testParticle) BasicEntity() *ecs.BasicEntity {
+	return &p.basicEntity
+}
+
+func (p *testParticle) ParticleComponent() *physics.ParticleComponent {
+	return &p.particleComponent
+}
+
+// steer builds an engine with a single 10x10 particle centered on the origin, steered by the stack,
+// and integrates it once. It returns the particle's velocity afterwards
+func steer(stack *Stack, velocity engo.Point) engo.Point {
+	p := &testParticle{
+		basicEntity:       ecs.NewBasic(),
+		particleComponent: physics.NewParticleComponent(10, 10, 2, engo.Point{-5, -5}, velocity),
+	}
+	e := physics.NewParticleEngine(engo.Point{}, 1, 0, nil, nil, nil)
+	e.Add(p)
+	e.AddForceGenerator(p.BasicEntity().ID(), stack)
+
+	e.Integrate(0.25)
+	return p.ParticleComponent().Velocity
+}
+
+func TestSeekSpeedsUpTowardsTarget(t *testing.T) {
+	stack := NewStack(100, 1000, 0.5)
+	stack.Push("seek", &Seek{Target: Point{100, 0}}, 1)
+
+	v := steer(stack, engo.Point{})
+	if v.X <= 0 || !engo.FloatEqual(v.Y, 0) {
+		t.Errorf("expected to move right towards the target, got velocity %v", v)
+	}
+}
+
+func TestFleeSpeedsUpAwayFromTarget(t *testing.T) {
+	stack := NewStack(100, 1000, 0.5)
+	stack.Push("flee", &Flee{Target: Point{0, 100}}, 1)
+
+	v := steer(stack, engo.Point{})
+	if v.Y >= 0 || !engo.FloatEqual(v.X, 0) {
+		t.Errorf("expected to move up away from the target, got velocity %v", v)
+	}
+}
+
+func TestFleeIgnoresTargetPastPanicDistance(t *testing.T) {
+	stack := NewStack(100, 1000, 0.5)
+	stack.Push("flee", &Flee{Target: Point{0, 100}, PanicDistance: 50}, 1)
+
+	if v := steer(stack, engo.Point{10, 0}); v != (engo.Point{10, 0}) {
+		t.Errorf("expected the velocity to be unchanged, got %v", v)
+	}
+}
+
+func TestArriveSlowsDownNearTarget(t *testing.T) {
+	stack := NewStack(100, 1000, 0.5)
+	stack.Push("arrive", &Arrive{Target: Point{10, 0}, SlowingRadius: 100}, 1)
+
+	v := steer(stack, engo.Point{100, 0})
+	if v.X >= 100 || v.X <= 0 {
+		t.Errorf("expected to slow down while still heading for the target, got velocity %v", v)
+	}
+}
+
+func TestStackTopBehaviourWins(t *testing.T) {
+	// the flee on top uses up all of the acceleration, so the seek underneath gets none
+	stack := NewStack(100, 100, 0.5)
+	stack.Push("seek", &Seek{Target: Point{100, 0}}, 1)
+	stack.Push("flee", &Flee{Target: Point{100, 0}}, 1)
+
+	v := steer(stack, engo.Point{})
+	if v.X >= 0 {
+		t.Errorf("expected to flee from the target, got velocity %v", v)
+	}
+
+	stack.Remove("flee")
+	if v := steer(stack, engo.Point{}); v.X <= 0 {
+		t.Errorf("expected to seek the target once the flee is removed, got velocity %v", v)
+	}
+}
@@ -0,0 +1,52 @@
+package steering
+
+import (
+	"engo.io/engo"
+	"github.com/bcokert/engo-test/physics"
+)
+
+// A Target is something that can be steered towards or away from
+type Target interface {
+	Position() engo.Point
+	Velocity() engo.Point
+}
+
+// A Point is a Target that never moves
+type Point engo.Point
+
+func (p Point) Position() engo.Point {
+	return engo.Point(p)
+}
+
+func (p Point) Velocity() engo.Point {
+	return engo.Point{}
+}
+
+// A Body is a Target that follows the center of a particle
+type Body struct {
+	Particle *physics.ParticleComponent
+}
+
+func (b Body) Position() engo.Point {
+	return center(b.Particle)
+}
+
+func (b Body) Velocity() engo.Point {
+	return b.Particle.Velocity
+}
+
+// A Cursor is a Target that follows the mouse
+type Cursor struct{}
+
+func (c Cursor) Position() engo.Point {
+	return engo.Point{engo.Input.Mouse.X, engo.Input.Mouse.Y}
+}
+
+func (c Cursor) Velocity() engo.Point {
+	return engo.Point{}
+}
+
+func center(body *physics.ParticleComponent) engo.Point {
+	space := body.SpaceComponent
+	return engo.Point{space.Position.X + space.Width/2, space.Position.Y + space.Height/2}
+}
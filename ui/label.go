@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"image/color"
+
+	"engo.io/ecs"
+	"engo.io/engo"
+	"engo.io/engo/common"
+)
+
+// FontURL is the font used for all text in the game, relative to the assets folder
+const FontURL = "fonts/DejaVuSansMono.ttf"
+
+// NewFont creates a font of the given size and color from the game's font, which must already be preloaded
+func NewFont(size float64, fg color.Color) (*common.Font, error) {
+	font := &common.Font{
+		URL:  FontURL,
+		FG:   fg,
+		Size: size,
+	}
+	if err := font.CreatePreloaded(); err != nil {
+		return nil, err
+	}
+	return font, nil
+}
+
+// A Label is an entity that displays a single line of text
+type Label struct {
+	basicEntity     ecs.BasicEntity
+	renderComponent common.RenderComponent
+	spaceComponent  common.SpaceComponent
+}
+
+// NewLabel creates a label with its top left corner at the given position
+func NewLabel(font *common.Font, text string, position engo.Point) *Label {
+	return &Label{
+		basicEntity: ecs.NewBasic(),
+		renderComponent: common.RenderComponent{
+			Drawable: common.Text{Font: font, Text: text},
+		},
+		spaceComponent: common.SpaceComponent{Position: position},
+	}
+}
+
+func (l *Label) BasicEntity() *ecs.BasicEntity {
+	return &l.basicEntity
+}
+
+func (l *Label) RenderComponent() *common.RenderComponent {
+	return &l.renderComponent
+}
+
+func (l *Label) SpaceComponent() *common.SpaceComponent {
+	return &l.spaceComponent
+}
+
+// SetText changes the text displayed by the label
+func (l *Label) SetText(text string) {
+	drawable := l.renderComponent.Drawable.(common.Text)
+	drawable.Text = text
+	l.renderComponent.Drawable = drawable
+}
+
+// AddToWorld registers the label with the world's RenderSystem
+func (l *Label) AddToWorld(world *ecs.World) {
+	for _, worldSystem := range world.Systems() {
+		switch targetSystem := worldSystem.(type) {
+		case *common.RenderSystem:
+			targetSystem.Add(l.BasicEntity(), l.RenderComponent(), l.SpaceComponent())
+		}
+	}
+}
+
+// A Panel is an entity that displays a solid colored rectangle
+type Panel struct {
+	basicEntity     ecs.BasicEntity
+	renderComponent common.RenderComponent
+	spaceComponent  common.SpaceComponent
+}
+
+// NewPanel creates a panel covering the given area
+func NewPanel(col color.Color, position engo.Point, width, height float32) *Panel {
+	return &Panel{
+		basicEntity: ecs.NewBasic(),
+		renderComponent: common.RenderComponent{
+			Drawable: common.Rectangle{},
+			Color:    col,
+		},
+		spaceComponent: common.SpaceComponent{Position: position, Width: width, Height: height},
+	}
+}
+
+func (p *Panel) BasicEntity() *ecs.BasicEntity {
+	return &p.basicEntity
+}
+
+func (p *Panel) RenderComponent() *common.RenderComponent {
+	return &p.renderComponent
+}
+
+func (p *Panel) SpaceComponent() *common.SpaceComponent {
+	return &p.spaceComponent
+}
+
+// AddToWorld registers the panel with the world's RenderSystem
+func (p *Panel) AddToWorld(world *ecs.World) {
+	for _, worldSystem := range world.Systems() {
+		switch targetSystem := worldSystem.(type) {
+		case *common.RenderSystem:
+			targetSystem.Add(p.BasicEntity(), p.RenderComponent(), p.SpaceComponent())
+		}
+	}
+}